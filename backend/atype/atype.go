@@ -69,17 +69,33 @@ type ArrayType struct {
 
 	// AxisLengths is the length of each axis. Its length determines the number of axes.
 	AxisLengths []int
+
+	// Quantization holds the quantization parameters if the array holds quantized values, otherwise it is nil.
+	// If set, DType is equal to Quantization.StorageDType. See MakeQuantized.
+	Quantization *QuantizedType
 }
 
 // Make returns an ArrayType structure filled with the values given.
+//
+// It panics if any of the axis lengths is negative. See MakeE for a version that returns an error instead.
 func Make(dtype dtype.DType, axisLengths ...int) ArrayType {
+	at, err := MakeE(dtype, axisLengths...)
+	if err != nil {
+		panic(err)
+	}
+	return at
+}
+
+// MakeE returns an ArrayType structure filled with the values given, or an error if any of
+// the axis lengths is negative.
+func MakeE(dtype dtype.DType, axisLengths ...int) (ArrayType, error) {
 	at := ArrayType{AxisLengths: slices.Clone(axisLengths), DType: dtype}
 	for _, length := range axisLengths {
 		if length < 0 {
-			panic(errors.Errorf("atype.Make(%s): cannot create an array type with an axis with length < 0", at))
+			return Invalid(), errors.Errorf("atype.MakeE(%s): cannot create an array type with an axis with length < 0", at)
 		}
 	}
-	return at
+	return at, nil
 }
 
 // Scalar returns a scalar ArrayType for the given type.
@@ -105,26 +121,40 @@ func (at ArrayType) IsScalar() bool { return at.Ok() && at.NumAxes() == 0 }
 
 // AxisLength returns the length of the given axis. axis can take negative numbers, in which
 // case it counts as starting from the end -- so axis=-1 refers to the last axis.
-// Like with a slice indexing, it panics for an out-of-bound axis.
+// Like with a slice indexing, it panics for an out-of-bound axis. See AxisLengthE for a version that
+// returns an error instead.
 func (at ArrayType) AxisLength(axis int) int {
+	length, err := at.AxisLengthE(axis)
+	if err != nil {
+		panic(err)
+	}
+	return length
+}
+
+// AxisLengthE returns the length of the given axis, like AxisLength, but it returns an error for an out-of-bound axis.
+func (at ArrayType) AxisLengthE(axis int) (int, error) {
 	adjustedAxis := axis
 	if adjustedAxis < 0 {
 		adjustedAxis += at.NumAxes()
 	}
 	if adjustedAxis < 0 || adjustedAxis >= at.NumAxes() {
-		panic(errors.Errorf("ArrayType.AxisLength(%d) out-of-bounds for NumAxes %d (arrayType=%s)", axis, at.NumAxes(), at))
+		return 0, errors.Errorf("ArrayType.AxisLength(%d) out-of-bounds for NumAxes %d (arrayType=%s)", axis, at.NumAxes(), at)
 	}
-	return at.AxisLengths[adjustedAxis]
+	return at.AxisLengths[adjustedAxis], nil
 }
 
 func (at ArrayType) ArrayType() ArrayType { return at }
 
 // String implements stringer, pretty-prints the array type.
 func (at ArrayType) String() string {
+	dtypeStr := at.DType.String()
+	if at.Quantization != nil {
+		dtypeStr = at.Quantization.String()
+	}
 	if at.NumAxes() == 0 {
-		return fmt.Sprintf("(%s)", at.DType)
+		return fmt.Sprintf("(%s)", dtypeStr)
 	}
-	return fmt.Sprintf("(%s)%v", at.DType, at.AxisLengths)
+	return fmt.Sprintf("(%s)%v", dtypeStr, at.AxisLengths)
 }
 
 // Size returns the number of elements (not bytes) for this array type. It's the product of all axis lengths.
@@ -156,16 +186,24 @@ func (at ArrayType) IsZeroSize() bool {
 
 // Memory returns the memory used to store an array of the given array type, the same as the size in bytes.
 // Careful, so far all types in Go and on device seem to use the same sizes, but future type this is not guaranteed.
+//
+// Sub-byte dtypes (like S4 or U2) are bit-packed in row-major order, and the memory is rounded up to
+// a whole number of bytes. See dtype.PackSubByte.
 func (at ArrayType) Memory() uintptr {
-	// FIXME: How to handle sub-byte types (like S2 etc.)
-	return at.DType.Memory() * uintptr(at.Size())
+	return uintptr(at.DType.SizeForAxes(at.AxisLengths...))
 }
 
-// Equal compares two array types for equality: dtype and axis lengths are compared.
+// Equal compares two array types for equality: dtype, axis lengths and quantization parameters are compared.
 func (at ArrayType) Equal(other ArrayType) bool {
 	if at.DType != other.DType {
 		return false
 	}
+	if (at.Quantization == nil) != (other.Quantization == nil) {
+		return false
+	}
+	if at.Quantization != nil && !at.Quantization.Equal(*other.Quantization) {
+		return false
+	}
 	if at.NumAxes() != other.NumAxes() {
 		return false
 	}
@@ -192,6 +230,10 @@ func (at ArrayType) EqualAxes(other ArrayType) bool {
 func (at ArrayType) Clone() (cloned ArrayType) {
 	cloned.DType = at.DType
 	cloned.AxisLengths = slices.Clone(at.AxisLengths)
+	if at.Quantization != nil {
+		quantization := at.Quantization.Clone()
+		cloned.Quantization = &quantization
+	}
 	return
 }
 
@@ -206,11 +248,22 @@ func (at ArrayType) GobSerialize(encoder *gob.Encoder) (err error) {
 			err = errors.Wrapf(err, "failed to serialize ArrayType %s", at)
 		}
 	}
+	if at.Quantization != nil {
+		enc(gobQuantizedMarker)
+	}
 	enc(at.DType)
 	enc(at.AxisLengths)
+	if at.Quantization != nil {
+		enc(at.Quantization)
+	}
 	return
 }
 
+// gobQuantizedMarker is written in place of the DType to flag a quantized ArrayType, which is followed by
+// the usual DType and AxisLengths and then the Quantization. Unquantized array types are encoded with just
+// the DType and AxisLengths, as before quantization was supported, so old encodings still decode.
+const gobQuantizedMarker = dtype.DType(-1)
+
 // GobDeserialize deserializes an ArrayType. Returns new ArrayType or an error.
 func GobDeserialize(decoder *gob.Decoder) (at ArrayType, err error) {
 	dec := func(data any) {
@@ -223,7 +276,15 @@ func GobDeserialize(decoder *gob.Decoder) (at ArrayType, err error) {
 		}
 	}
 	dec(&at.DType)
+	isQuantized := at.DType == gobQuantizedMarker
+	if isQuantized {
+		dec(&at.DType)
+	}
 	dec(&at.AxisLengths)
+	if isQuantized {
+		at.Quantization = &QuantizedType{}
+		dec(at.Quantization)
+	}
 	return
 }
 
@@ -0,0 +1,191 @@
+package atype
+
+import (
+	"slices"
+
+	"github.com/pkg/errors"
+)
+
+// This file implements helpers to derive array types by manipulating axes, e.g. for the output of layers.
+//
+// All of them accept negative axes, counting from the end, and return a new array type, leaving the
+// original unchanged. Per-axis quantization follows the quantized axis, and it is an error to merge or split it.
+
+// normalizeAxis converts a possibly negative axis to its index in [0, numAxes), or returns false if it is
+// out-of-bounds.
+func normalizeAxis(axis, numAxes int) (int, bool) {
+	if axis < 0 {
+		axis += numAxes
+	}
+	return axis, axis >= 0 && axis < numAxes
+}
+
+// withAxisLengths returns a clone of at with the given axis lengths, and the quantized axis (if per-axis
+// quantized) moved to quantizedAxis.
+func (at ArrayType) withAxisLengths(axisLengths []int, quantizedAxis func(axis int) int) ArrayType {
+	result := at.Clone()
+	result.AxisLengths = axisLengths
+	if result.IsQuantized() && result.Quantization.IsPerAxis() {
+		result.Quantization.Axis = quantizedAxis(result.Quantization.Axis)
+	}
+	return result
+}
+
+// quantizedAxisIn returns whether at is per-axis quantized on one of the axes in [from, to].
+func (at ArrayType) quantizedAxisIn(from, to int) bool {
+	return at.IsQuantized() && at.Quantization.IsPerAxis() && at.Quantization.Axis >= from && at.Quantization.Axis <= to
+}
+
+// InsertAxis returns the array type with a new axis of length 1 inserted at position axis, in [0, NumAxes()].
+// Negative values count from the end, so -1 appends a new last axis.
+//
+// Example:
+//
+//	Make(dtype.Float32, 2, 3).InsertAxis(0)  // (Float32)[1 2 3]
+//	Make(dtype.Float32, 2, 3).InsertAxis(-1) // (Float32)[2 3 1]
+func (at ArrayType) InsertAxis(axis int) (ArrayType, error) {
+	if !at.Ok() {
+		return Invalid(), errors.Errorf("ArrayType.InsertAxis(%d): invalid array type", axis)
+	}
+	position, ok := normalizeAxis(axis, at.NumAxes()+1)
+	if !ok {
+		return Invalid(), errors.Errorf("ArrayType.InsertAxis(%d) out-of-bounds for %s", axis, at)
+	}
+	axisLengths := slices.Insert(slices.Clone(at.AxisLengths), position, 1)
+	return at.withAxisLengths(axisLengths, func(q int) int {
+		if q >= position {
+			return q + 1
+		}
+		return q
+	}), nil
+}
+
+// RemoveAxis returns the array type without the given axis, e.g. for the output of a reduction over it.
+//
+// It returns an error if the axis is out-of-bounds, or if it is the quantized axis of a per-axis quantized array type.
+func (at ArrayType) RemoveAxis(axis int) (ArrayType, error) {
+	if !at.Ok() {
+		return Invalid(), errors.Errorf("ArrayType.RemoveAxis(%d): invalid array type", axis)
+	}
+	position, ok := normalizeAxis(axis, at.NumAxes())
+	if !ok {
+		return Invalid(), errors.Errorf("ArrayType.RemoveAxis(%d) out-of-bounds for %s", axis, at)
+	}
+	if at.quantizedAxisIn(position, position) {
+		return Invalid(), errors.Errorf("ArrayType.RemoveAxis(%d): cannot remove the quantized axis of %s", axis, at)
+	}
+	axisLengths := slices.Delete(slices.Clone(at.AxisLengths), position, position+1)
+	return at.withAxisLengths(axisLengths, func(q int) int {
+		if q > position {
+			return q - 1
+		}
+		return q
+	}), nil
+}
+
+// SwapAxes returns the array type with the axes axis1 and axis2 swapped.
+func (at ArrayType) SwapAxes(axis1, axis2 int) (ArrayType, error) {
+	if !at.Ok() {
+		return Invalid(), errors.Errorf("ArrayType.SwapAxes(%d, %d): invalid array type", axis1, axis2)
+	}
+	position1, ok1 := normalizeAxis(axis1, at.NumAxes())
+	position2, ok2 := normalizeAxis(axis2, at.NumAxes())
+	if !ok1 || !ok2 {
+		return Invalid(), errors.Errorf("ArrayType.SwapAxes(%d, %d) out-of-bounds for %s", axis1, axis2, at)
+	}
+	axisLengths := slices.Clone(at.AxisLengths)
+	axisLengths[position1], axisLengths[position2] = axisLengths[position2], axisLengths[position1]
+	return at.withAxisLengths(axisLengths, func(q int) int {
+		switch q {
+		case position1:
+			return position2
+		case position2:
+			return position1
+		default:
+			return q
+		}
+	}), nil
+}
+
+// MergeAxes returns the array type with the consecutive axes from fromAxis to toAxis (inclusive) merged into
+// one axis, whose length is the product of their lengths. The number of elements is unchanged.
+//
+// Example:
+//
+//	Make(dtype.Float32, 2, 3, 4, 5).MergeAxes(1, 2) // (Float32)[2 12 5]
+func (at ArrayType) MergeAxes(fromAxis, toAxis int) (ArrayType, error) {
+	if !at.Ok() {
+		return Invalid(), errors.Errorf("ArrayType.MergeAxes(%d, %d): invalid array type", fromAxis, toAxis)
+	}
+	from, okFrom := normalizeAxis(fromAxis, at.NumAxes())
+	to, okTo := normalizeAxis(toAxis, at.NumAxes())
+	if !okFrom || !okTo || from > to {
+		return Invalid(), errors.Errorf("ArrayType.MergeAxes(%d, %d): invalid axes range for %s", fromAxis, toAxis, at)
+	}
+	if from < to && at.quantizedAxisIn(from, to) {
+		return Invalid(), errors.Errorf("ArrayType.MergeAxes(%d, %d): cannot merge the quantized axis of %s", fromAxis, toAxis, at)
+	}
+	merged := 1
+	for _, length := range at.AxisLengths[from : to+1] {
+		merged *= length
+	}
+	axisLengths := slices.Replace(slices.Clone(at.AxisLengths), from, to+1, merged)
+	return at.withAxisLengths(axisLengths, func(q int) int {
+		if q > to {
+			return q - (to - from)
+		}
+		return q
+	}), nil
+}
+
+// SplitAxis returns the array type with the given axis split into consecutive axes with the given lengths,
+// whose product must equal the length of the axis. One of the lengths can be -1, in which case it is inferred.
+//
+// Example:
+//
+//	Make(dtype.Float32, 2, 12).SplitAxis(-1, 3, -1) // (Float32)[2 3 4]
+func (at ArrayType) SplitAxis(axis int, axisLengths ...int) (ArrayType, error) {
+	if !at.Ok() {
+		return Invalid(), errors.Errorf("ArrayType.SplitAxis(%d, %v): invalid array type", axis, axisLengths)
+	}
+	position, ok := normalizeAxis(axis, at.NumAxes())
+	if !ok {
+		return Invalid(), errors.Errorf("ArrayType.SplitAxis(%d, %v) out-of-bounds for %s", axis, axisLengths, at)
+	}
+	if len(axisLengths) == 0 {
+		return Invalid(), errors.Errorf("ArrayType.SplitAxis(%d): no axis lengths given", axis)
+	}
+	if len(axisLengths) > 1 && at.quantizedAxisIn(position, position) {
+		return Invalid(), errors.Errorf("ArrayType.SplitAxis(%d, %v): cannot split the quantized axis of %s", axis, axisLengths, at)
+	}
+	length := at.AxisLengths[position]
+	splitLengths := slices.Clone(axisLengths)
+	product, inferred := 1, -1
+	for ii, splitLength := range splitLengths {
+		switch {
+		case splitLength == -1 && inferred < 0:
+			inferred = ii
+		case splitLength < 0:
+			return Invalid(), errors.Errorf("ArrayType.SplitAxis(%d, %v): invalid axis lengths, at most one can be -1", axis, axisLengths)
+		default:
+			product *= splitLength
+		}
+	}
+	if inferred >= 0 {
+		if product == 0 || length%product != 0 {
+			return Invalid(), errors.Errorf("ArrayType.SplitAxis(%d, %v): cannot infer the axis length to split axis of length %d", axis, axisLengths, length)
+		}
+		splitLengths[inferred] = length / product
+		product = length
+	}
+	if product != length {
+		return Invalid(), errors.Errorf("ArrayType.SplitAxis(%d, %v): product of lengths %d doesn't match the axis length %d", axis, axisLengths, product, length)
+	}
+	newAxisLengths := slices.Replace(slices.Clone(at.AxisLengths), position, position+1, splitLengths...)
+	return at.withAxisLengths(newAxisLengths, func(q int) int {
+		if q > position {
+			return q + len(splitLengths) - 1
+		}
+		return q
+	}), nil
+}
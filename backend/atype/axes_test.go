@@ -0,0 +1,94 @@
+package atype
+
+import (
+	"testing"
+
+	"github.com/sebffischer/backend/backend/dtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAxesHelpers(t *testing.T) {
+	at := Make(dtype.Float32, 2, 3, 4)
+
+	got, err := at.InsertAxis(0)
+	require.NoError(t, err)
+	require.True(t, got.Equal(Make(dtype.Float32, 1, 2, 3, 4)), "got %s", got)
+	got, err = at.InsertAxis(-1)
+	require.NoError(t, err)
+	require.True(t, got.Equal(Make(dtype.Float32, 2, 3, 4, 1)), "got %s", got)
+	_, err = at.InsertAxis(3)
+	require.NoError(t, err)
+	_, err = at.InsertAxis(4)
+	require.Error(t, err)
+	_, err = at.InsertAxis(-5)
+	require.Error(t, err)
+
+	got, err = at.RemoveAxis(-2)
+	require.NoError(t, err)
+	require.True(t, got.Equal(Make(dtype.Float32, 2, 4)), "got %s", got)
+	_, err = at.RemoveAxis(3)
+	require.Error(t, err)
+
+	got, err = at.SwapAxes(0, -1)
+	require.NoError(t, err)
+	require.True(t, got.Equal(Make(dtype.Float32, 4, 3, 2)), "got %s", got)
+
+	got, err = at.MergeAxes(1, 2)
+	require.NoError(t, err)
+	require.True(t, got.Equal(Make(dtype.Float32, 2, 12)), "got %s", got)
+	got, err = at.MergeAxes(0, -1)
+	require.NoError(t, err)
+	require.True(t, got.Equal(Make(dtype.Float32, 24)), "got %s", got)
+	_, err = at.MergeAxes(2, 1)
+	require.Error(t, err)
+
+	got, err = at.SplitAxis(-1, 2, -1)
+	require.NoError(t, err)
+	require.True(t, got.Equal(Make(dtype.Float32, 2, 3, 2, 2)), "got %s", got)
+	got, err = at.SplitAxis(1, 1, 3)
+	require.NoError(t, err)
+	require.True(t, got.Equal(Make(dtype.Float32, 2, 1, 3, 4)), "got %s", got)
+	_, err = at.SplitAxis(1, 2, 2)
+	require.Error(t, err)
+	_, err = at.SplitAxis(1, -1, -1)
+	require.Error(t, err)
+	_, err = at.SplitAxis(2, 3, -1)
+	require.Error(t, err)
+
+	// The original is unchanged.
+	require.True(t, at.Equal(Make(dtype.Float32, 2, 3, 4)))
+
+	// Invalid array type.
+	_, err = Invalid().InsertAxis(0)
+	require.Error(t, err)
+}
+
+func TestAxesHelpersQuantized(t *testing.T) {
+	qt, err := NewPerAxisQuantizedType(dtype.Int8, dtype.Float32, 1, []float64{1, 2, 3}, []int64{0, 0, 0})
+	require.NoError(t, err)
+	at, err := MakeQuantized(qt, 4, 3, 2)
+	require.NoError(t, err)
+
+	got, err := at.InsertAxis(0)
+	require.NoError(t, err)
+	require.Equal(t, 2, got.Quantization.Axis)
+	require.Equal(t, 1, at.Quantization.Axis)
+
+	got, err = at.SwapAxes(1, 2)
+	require.NoError(t, err)
+	require.Equal(t, 2, got.Quantization.Axis)
+
+	got, err = at.RemoveAxis(0)
+	require.NoError(t, err)
+	require.Equal(t, 0, got.Quantization.Axis)
+	_, err = at.RemoveAxis(1)
+	require.Error(t, err)
+
+	_, err = at.MergeAxes(0, 1)
+	require.Error(t, err)
+	_, err = at.SplitAxis(1, 3, 1)
+	require.Error(t, err)
+	got, err = at.SplitAxis(0, 2, 2)
+	require.NoError(t, err)
+	require.Equal(t, 2, got.Quantization.Axis)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/sebffischer/backend/backend/dtype"
 	"github.com/sebffischer/backend/backend/dtype/bfloat16"
+	"github.com/sebffischer/backend/backend/dtype/float8"
 	"github.com/x448/float16"
 )
 
@@ -38,6 +39,10 @@ func ConvertTo[T dtype.NumberNotComplex](value any) T {
 		return T(v.Float32())
 	case bfloat16.BFloat16:
 		return T(v.Float32())
+	case float8.E4M3FN:
+		return T(v.Float32())
+	case float8.E5M2:
+		return T(v.Float32())
 	case int:
 		return T(v)
 	case int64:
@@ -97,6 +102,10 @@ func UnsafeSliceForDType(dt dtype.DType, unsafePtr unsafe.Pointer, len int) (any
 		val = unsafe.Slice((*float16.Float16)(unsafePtr), len)
 	case dtype.BFloat16:
 		val = unsafe.Slice((*bfloat16.BFloat16)(unsafePtr), len)
+	case dtype.F8E4M3FN:
+		val = unsafe.Slice((*float8.E4M3FN)(unsafePtr), len)
+	case dtype.F8E5M2:
+		val = unsafe.Slice((*float8.E5M2)(unsafePtr), len)
 	case dtype.Float32:
 		val = unsafe.Slice((*float32)(unsafePtr), len)
 	case dtype.Float64:
@@ -153,6 +162,14 @@ func CastAsDType(value any, dt dtype.DType) any {
 			v32 := valueOf.Convert(float32Type).Interface().(float32)
 			return bfloat16.FromFloat32(v32)
 		}
+		if dt == dtype.F8E4M3FN {
+			v32 := valueOf.Convert(float32Type).Interface().(float32)
+			return float8.E4M3FNFromFloat32(v32)
+		}
+		if dt == dtype.F8E5M2 {
+			v32 := valueOf.Convert(float32Type).Interface().(float32)
+			return float8.E5M2FromFloat32(v32)
+		}
 		// TODO: if adding support for non-native Go types (e.g: BFloat16), we need
 		//       to write our own conversion here.
 		return valueOf.Convert(newTypeOf).Interface()
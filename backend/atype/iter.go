@@ -49,10 +49,19 @@ func (at ArrayType) Iter() iter.Seq2[int, []int] {
 // The iteration updates the indices on the given indices slice.
 // During the iteration the caller shouldn't modify the slice of indices, otherwise it will lead to undefined behavior.
 //
-// It expects len(indices) == at.NumAxes(). It will panic otherwise.
+// It expects len(indices) == at.NumAxes(). It will panic otherwise. See IterOnE for a version that returns an error instead.
 func (at ArrayType) IterOn(indices []int) iter.Seq2[int, []int] {
+	seq, err := at.IterOnE(indices)
+	if err != nil {
+		panic(err)
+	}
+	return seq
+}
+
+// IterOnE is like IterOn, but it returns an error if len(indices) != at.NumAxes().
+func (at ArrayType) IterOnE(indices []int) (iter.Seq2[int, []int], error) {
 	if len(indices) != at.NumAxes() {
-		panic(errors.Errorf("ArrayType.IterOn given len(indices) == %d, want it to be equal to the number of axes %d", len(indices), at.NumAxes()))
+		return nil, errors.Errorf("ArrayType.IterOn given len(indices) == %d, want it to be equal to the number of axes %d", len(indices), at.NumAxes())
 	}
 	return func(yield func(int, []int) bool) {
 		if !at.Ok() {
@@ -158,7 +167,7 @@ func (at ArrayType) IterOn(indices []int) iter.Seq2[int, []int] {
 			// That was the last index.
 			break
 		}
-	}
+	}, nil
 }
 
 // IterOnAxes iterates over all possible indices of the given array type's axesToIterate.
@@ -193,21 +202,40 @@ func (at ArrayType) IterOn(indices []int) iter.Seq2[int, []int] {
 //	for flatIdx, indices := range arrayType.IterOnAxes(axesToIterate, nil, indices) {
 //	    fmt.Printf("flatIdx=%d, indices=%v\n", flatIdx, indices)
 //	}
+//
+// See IterOnAxesE for a version that returns an error instead of panicking.
 func (at ArrayType) IterOnAxes(axesToIterate, strides, indices []int) iter.Seq2[int, []int] {
+	seq, err := at.IterOnAxesE(axesToIterate, strides, indices)
+	if err != nil {
+		panic(err)
+	}
+	return seq
+}
+
+// IterOnAxesE is like IterOnAxes, but it returns an error if strides or indices have the wrong length,
+// or if any of axesToIterate is out of bounds.
+func (at ArrayType) IterOnAxesE(axesToIterate, strides, indices []int) (iter.Seq2[int, []int], error) {
 	numAxes := at.NumAxes()
 
 	// Validate and initialize strides
 	if strides == nil {
 		strides = at.Strides()
 	} else if len(strides) != numAxes {
-		panic(errors.Errorf("ArrayType.IterOnAxes given len(strides) == %d, want it to be equal to the NumAxes %d", len(strides), numAxes))
+		return nil, errors.Errorf("ArrayType.IterOnAxes given len(strides) == %d, want it to be equal to the NumAxes %d", len(strides), numAxes)
 	}
 
 	// Validate and initialize indices
 	if indices == nil {
 		indices = make([]int, numAxes)
 	} else if len(indices) != numAxes {
-		panic(errors.Errorf("ArrayType.IterOnAxes given len(indices) == %d, want it to be equal to the NumAxes %d", len(indices), numAxes))
+		return nil, errors.Errorf("ArrayType.IterOnAxes given len(indices) == %d, want it to be equal to the NumAxes %d", len(indices), numAxes)
+	}
+
+	// Validate axes to iterate
+	for _, axis := range axesToIterate {
+		if axis < 0 || axis >= numAxes {
+			return nil, errors.Errorf("ArrayType.IterOnAxes: invalid axis %d, must be 0 <= axis < NumAxes (%d)", axis, numAxes)
+		}
 	}
 
 	return func(yield func(int, []int) bool) {
@@ -223,9 +251,6 @@ func (at ArrayType) IterOnAxes(axesToIterate, strides, indices []int) iter.Seq2[
 
 		// Defensive check: if any axis length to iterate is non-positive, treat as an empty iteration.
 		for _, axis := range axesToIterate {
-			if axis < 0 || axis >= numAxes {
-				panic(errors.Errorf("ArrayType.IterOnAxes: invalid axis %d, must be 0 <= axis < NumAxes (%d)", axis, numAxes))
-			}
 			if at.AxisLengths[axis] <= 0 {
 				return
 			}
@@ -264,5 +289,144 @@ func (at ArrayType) IterOnAxes(axesToIterate, strides, indices []int) iter.Seq2[
 			// That was the last index.
 			break
 		}
+	}, nil
+}
+
+// IterTiles iterates over the tiles (blocks) of the array type with the given tile sizes, one per axis, in
+// row-major order of the tiles.
+//
+// It yields the origin (the indices of the first element) of each tile and its axis lengths. Tiles on the
+// edges are smaller when an axis length is not a multiple of the tile size. Scalars yield one tile with no axes,
+// and array types with a zero-length axis yield no tiles.
+//
+// To avoid allocations, the yielded slices are owned by the iterator: don't change them inside the loop.
+//
+// Example:
+//
+//	// Tiles of (Float32)[5 4] with tile sizes [2 4]: origins [0 0], [2 0] and [4 0],
+//	// with lengths [2 4], [2 4] and [1 4].
+//	for origin, lengths := range Make(dtype.Float32, 5, 4).IterTiles([]int{2, 4}) { ... }
+//
+// It panics if len(tileSizes) != at.NumAxes() or if any tile size is not positive. See IterTilesE for a version
+// that returns an error instead.
+func (at ArrayType) IterTiles(tileSizes []int) iter.Seq2[[]int, []int] {
+	seq, err := at.IterTilesE(tileSizes)
+	if err != nil {
+		panic(err)
+	}
+	return seq
+}
+
+// IterTilesE is like IterTiles, but it returns an error if len(tileSizes) != at.NumAxes() or if any tile size is
+// not positive.
+func (at ArrayType) IterTilesE(tileSizes []int) (iter.Seq2[[]int, []int], error) {
+	if len(tileSizes) != at.NumAxes() {
+		return nil, errors.Errorf("ArrayType.IterTiles given len(tileSizes) == %d, want it to be equal to the number of axes %d", len(tileSizes), at.NumAxes())
+	}
+	gridLengths := make([]int, at.NumAxes())
+	for axis, tileSize := range tileSizes {
+		if tileSize <= 0 {
+			return nil, errors.Errorf("ArrayType.IterTiles given invalid tile size %d for axis %d, it must be positive", tileSize, axis)
+		}
+		gridLengths[axis] = (at.AxisLengths[axis] + tileSize - 1) / tileSize
+	}
+	grid := ArrayType{DType: at.DType, AxisLengths: gridLengths}
+	return func(yield func([]int, []int) bool) {
+		origin := make([]int, at.NumAxes())
+		lengths := make([]int, at.NumAxes())
+		for _, gridIndices := range grid.Iter() {
+			for axis, gridIdx := range gridIndices {
+				origin[axis] = gridIdx * tileSizes[axis]
+				lengths[axis] = min(tileSizes[axis], at.AxisLengths[axis]-origin[axis])
+			}
+			if !yield(origin, lengths) {
+				return
+			}
+		}
+	}, nil
+}
+
+// FlatRange is a contiguous range [Start, End) of flat indices (in row-major order) of an array type,
+// with the indices of its first element. See ArrayType.IterRangesParallel.
+type FlatRange struct {
+	Start, End int
+
+	// StartIndices are the indices of the element at flat index Start.
+	StartIndices []int
+}
+
+// Len returns the number of elements in the range.
+func (r FlatRange) Len() int { return r.End - r.Start }
+
+// IterRangesParallel partitions the flat index space of the array type into at most numWorkers contiguous
+// ranges of (almost) equal size, and yields the index of each range and the range itself.
+//
+// Each range owns its StartIndices, so ranges can be handed to separate goroutines, which can use IterRange to
+// iterate over their elements.
+//
+// Example:
+//
+//	var wg sync.WaitGroup
+//	for _, r := range arrayType.IterRangesParallel(runtime.NumCPU()) {
+//		wg.Go(func() {
+//			for flatIdx, indices := range arrayType.IterRange(r) { ... }
+//		})
+//	}
+//	wg.Wait()
+//
+// If numWorkers < 1, a single range is yielded. Empty (zero-size) array types yield no ranges.
+func (at ArrayType) IterRangesParallel(numWorkers int) iter.Seq2[int, FlatRange] {
+	return func(yield func(int, FlatRange) bool) {
+		if !at.Ok() {
+			return
+		}
+		size := at.Size()
+		if size == 0 {
+			return
+		}
+		numRanges := min(max(numWorkers, 1), size)
+		strides := at.Strides()
+		for ii := range numRanges {
+			r := FlatRange{Start: ii * size / numRanges, End: (ii + 1) * size / numRanges}
+			r.StartIndices = make([]int, at.NumAxes())
+			remainder := r.Start
+			for axis, stride := range strides {
+				r.StartIndices[axis] = remainder / stride
+				remainder %= stride
+			}
+			if !yield(ii, r) {
+				return
+			}
+		}
+	}
+}
+
+// IterRange iterates over the elements of the array type in the given range (see IterRangesParallel),
+// in row-major order.
+//
+// It yields the flat index and the indices for each element. The yielded indices slice is owned by the iterator:
+// don't change it inside the loop. It is safe to iterate over different ranges concurrently.
+func (at ArrayType) IterRange(r FlatRange) iter.Seq2[int, []int] {
+	return func(yield func(int, []int) bool) {
+		if r.Start >= r.End {
+			return
+		}
+		indices := slices.Clone(r.StartIndices)
+		for flatIdx := r.Start; ; {
+			if !yield(flatIdx, indices) {
+				return
+			}
+			flatIdx++
+			if flatIdx >= r.End {
+				return
+			}
+			for axis := at.NumAxes() - 1; axis >= 0; axis-- {
+				indices[axis]++
+				if indices[axis] < at.AxisLengths[axis] {
+					break
+				}
+				indices[axis] = 0
+			}
+		}
 	}
 }
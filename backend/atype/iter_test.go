@@ -2,6 +2,7 @@ package atype
 
 import (
 	"slices"
+	"sync"
 	"testing"
 
 	"github.com/sebffischer/backend/backend/dtype"
@@ -100,3 +101,123 @@ func TestArrayType_IterOnAxes(t *testing.T) {
 	}, collect)
 	require.Equal(t, []int{4, 5, 6, 7, 16, 17, 18, 19}, flatIndices)
 }
+
+func TestArrayType_IterOnE(t *testing.T) {
+	arrayType := Make(dtype.Float32, 2, 3)
+	_, err := arrayType.IterOnE(make([]int, 1))
+	require.Error(t, err)
+	require.Panics(t, func() { _ = arrayType.IterOn(make([]int, 1)) })
+
+	seq, err := arrayType.IterOnE(make([]int, 2))
+	require.NoError(t, err)
+	count := 0
+	for range seq {
+		count++
+	}
+	require.Equal(t, 6, count)
+
+	_, err = arrayType.IterOnAxesE([]int{2}, nil, nil)
+	require.Error(t, err)
+	_, err = arrayType.IterOnAxesE([]int{0}, []int{1}, nil)
+	require.Error(t, err)
+	_, err = arrayType.IterOnAxesE([]int{0}, nil, []int{0})
+	require.Error(t, err)
+	require.Panics(t, func() { _ = arrayType.IterOnAxes([]int{-1}, nil, nil) })
+}
+
+func TestArrayType_IterTiles(t *testing.T) {
+	at := Make(dtype.Float32, 5, 4)
+	var origins, lengths [][]int
+	for origin, tileLengths := range at.IterTiles([]int{2, 3}) {
+		origins = append(origins, slices.Clone(origin))
+		lengths = append(lengths, slices.Clone(tileLengths))
+	}
+	require.Equal(t, [][]int{{0, 0}, {0, 3}, {2, 0}, {2, 3}, {4, 0}, {4, 3}}, origins)
+	require.Equal(t, [][]int{{2, 3}, {2, 1}, {2, 3}, {2, 1}, {1, 3}, {1, 1}}, lengths)
+
+	// Tiles cover all elements exactly once.
+	at = Make(dtype.Int32, 7, 1, 9)
+	count := 0
+	for _, tileLengths := range at.IterTiles([]int{3, 5, 4}) {
+		count += Make(dtype.Int32, tileLengths...).Size()
+	}
+	require.Equal(t, at.Size(), count)
+
+	// Early break.
+	count = 0
+	for range at.IterTiles([]int{1, 1, 1}) {
+		count++
+		if count == 3 {
+			break
+		}
+	}
+	require.Equal(t, 3, count)
+
+	// Scalar yields one tile, zero-size yields none.
+	count = 0
+	for origin := range Make(dtype.Float32).IterTiles(nil) {
+		require.Empty(t, origin)
+		count++
+	}
+	require.Equal(t, 1, count)
+	for range Make(dtype.Float32, 3, 0).IterTiles([]int{2, 2}) {
+		t.Fatal("zero-size array type should yield no tiles")
+	}
+
+	// Invalid tile sizes.
+	_, err := at.IterTilesE([]int{1, 1})
+	require.Error(t, err)
+	_, err = at.IterTilesE([]int{1, 0, 1})
+	require.Error(t, err)
+	require.Panics(t, func() { at.IterTiles(nil) })
+}
+
+func TestArrayType_IterRangesParallel(t *testing.T) {
+	at := Make(dtype.Float32, 3, 1, 5)
+	var ranges []FlatRange
+	for ii, r := range at.IterRangesParallel(4) {
+		require.Equal(t, len(ranges), ii)
+		ranges = append(ranges, r)
+	}
+	require.Len(t, ranges, 4)
+	require.Equal(t, FlatRange{Start: 0, End: 3, StartIndices: []int{0, 0, 0}}, ranges[0])
+	require.Equal(t, FlatRange{Start: 3, End: 7, StartIndices: []int{0, 0, 3}}, ranges[1])
+	require.Equal(t, FlatRange{Start: 11, End: 15, StartIndices: []int{2, 0, 1}}, ranges[3])
+
+	// Iterating over all ranges concurrently matches the sequential iteration.
+	want := make([][]int, at.Size())
+	for flatIdx, indices := range at.Iter() {
+		want[flatIdx] = slices.Clone(indices)
+	}
+	got := make([][]int, at.Size())
+	var wg sync.WaitGroup
+	for _, r := range ranges {
+		wg.Go(func() {
+			for flatIdx, indices := range at.IterRange(r) {
+				got[flatIdx] = slices.Clone(indices)
+			}
+		})
+	}
+	wg.Wait()
+	require.Equal(t, want, got)
+
+	// More workers than elements, fewer than 1 worker, scalar and zero-size array types.
+	count := 0
+	for _, r := range Make(dtype.Int8, 2).IterRangesParallel(10) {
+		require.Equal(t, 1, r.Len())
+		count++
+	}
+	require.Equal(t, 2, count)
+	count = 0
+	for _, r := range at.IterRangesParallel(0) {
+		require.Equal(t, at.Size(), r.Len())
+		count++
+	}
+	require.Equal(t, 1, count)
+	for _, r := range Make(dtype.Int8).IterRangesParallel(3) {
+		require.Equal(t, FlatRange{Start: 0, End: 1, StartIndices: []int{}}, r)
+	}
+	for range Make(dtype.Int8, 0, 3).IterRangesParallel(3) {
+		t.Fatal("zero-size array type should yield no ranges")
+	}
+}
@@ -0,0 +1,123 @@
+package atype
+
+import (
+	"slices"
+
+	"github.com/pkg/errors"
+)
+
+// Layout describes where the elements of an array are located in a flat storage, in number of
+// elements (not bytes): the element at indices is at position
+//
+//	Offset + sum_i(indices[i] * Strides[i])
+//
+// Layouts other than the row-major one (see RowMajorLayout) represent views of a storage, for instance
+// a sub-range of an axis or a permutation of the axes, without copying the data.
+type Layout struct {
+	// Offset of the first element in the flat storage.
+	Offset int
+
+	// Strides for each axis. Its length must be equal to the number of axes of the array.
+	Strides []int
+}
+
+// RowMajorLayout returns the default row-major layout for the array type, with offset 0 and strides
+// given by ArrayType.Strides.
+func RowMajorLayout(at ArrayType) Layout {
+	return Layout{Strides: at.Strides()}
+}
+
+// ColumnMajorLayout returns the column-major (Fortran, BLAS) layout for the array type: the first axis is the
+// one that varies fastest in memory.
+func ColumnMajorLayout(at ArrayType) Layout {
+	minorToMajor := make([]int, at.NumAxes())
+	for axis := range minorToMajor {
+		minorToMajor[axis] = axis
+	}
+	layout, _ := LayoutFromMinorToMajor(at, minorToMajor)
+	return layout
+}
+
+// LayoutFromMinorToMajor returns the contiguous layout for the array type where the axes are ordered in memory
+// as given by minorToMajor, from the fastest to the slowest varying axis, the convention used by XLA.
+// For instance, for an array type with 2 axes, [1, 0] is the row-major layout and [0, 1] the column-major layout.
+//
+// It returns an error if minorToMajor is not a permutation of the axes.
+func LayoutFromMinorToMajor(at ArrayType, minorToMajor []int) (Layout, error) {
+	numAxes := at.NumAxes()
+	if len(minorToMajor) != numAxes {
+		return Layout{}, errors.Errorf("atype.LayoutFromMinorToMajor(%s, %v): wanted one entry per axis", at, minorToMajor)
+	}
+	if numAxes == 0 {
+		// Scalars have nil strides, like RowMajorLayout.
+		return Layout{}, nil
+	}
+	strides := make([]int, numAxes)
+	seen := make([]bool, numAxes)
+	stride := 1
+	for _, axis := range minorToMajor {
+		if axis < 0 || axis >= numAxes || seen[axis] {
+			return Layout{}, errors.Errorf("atype.LayoutFromMinorToMajor(%s, %v): not a permutation of the axes", at, minorToMajor)
+		}
+		seen[axis] = true
+		strides[axis] = stride
+		stride *= at.AxisLengths[axis]
+	}
+	if at.IsZeroSize() {
+		clear(strides)
+	}
+	return Layout{Strides: strides}, nil
+}
+
+// FlatIndex returns the position in the flat storage of the element at the given indices.
+// It expects len(indices) == len(l.Strides) and doesn't check bounds.
+func (l Layout) FlatIndex(indices []int) int {
+	flatIdx := l.Offset
+	for axis, idx := range indices {
+		flatIdx += idx * l.Strides[axis]
+	}
+	return flatIdx
+}
+
+// IsRowMajor returns whether the layout stores the elements of an array of type at contiguously in row-major
+// order, starting at l.Offset. The strides of axes with length 1 are irrelevant and not checked.
+// It returns false if the layout doesn't have one stride per axis.
+func (l Layout) IsRowMajor(at ArrayType) bool {
+	if len(l.Strides) != at.NumAxes() {
+		return false
+	}
+	if at.IsZeroSize() {
+		return true
+	}
+	rowMajorStrides := at.Strides()
+	for axis, length := range at.AxisLengths {
+		if length > 1 && l.Strides[axis] != rowMajorStrides[axis] {
+			return false
+		}
+	}
+	return true
+}
+
+// IsColumnMajor returns whether the layout stores the elements of an array of type at contiguously in
+// column-major order, starting at l.Offset. The strides of axes with length 1 are irrelevant and not checked.
+// It returns false if the layout doesn't have one stride per axis.
+func (l Layout) IsColumnMajor(at ArrayType) bool {
+	if len(l.Strides) != at.NumAxes() {
+		return false
+	}
+	if at.IsZeroSize() {
+		return true
+	}
+	columnMajor := ColumnMajorLayout(at)
+	for axis, length := range at.AxisLengths {
+		if length > 1 && l.Strides[axis] != columnMajor.Strides[axis] {
+			return false
+		}
+	}
+	return true
+}
+
+// Clone returns a deep copy of the layout.
+func (l Layout) Clone() Layout {
+	return Layout{Offset: l.Offset, Strides: slices.Clone(l.Strides)}
+}
@@ -0,0 +1,56 @@
+package atype
+
+import (
+	"testing"
+
+	"github.com/sebffischer/backend/backend/dtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayout(t *testing.T) {
+	arrayType := Make(dtype.Float32, 2, 3, 4)
+	layout := RowMajorLayout(arrayType)
+	require.True(t, layout.IsRowMajor(arrayType))
+	for flatIdx, indices := range arrayType.Iter() {
+		require.Equal(t, flatIdx, layout.FlatIndex(indices))
+	}
+
+	// Transposed axes 0 and 2.
+	transposed := Layout{Offset: 1, Strides: []int{1, 4, 12}}
+	require.False(t, transposed.IsRowMajor(Make(dtype.Float32, 4, 3, 2)))
+	require.Equal(t, 1+3+8+12, transposed.FlatIndex([]int{3, 2, 1}))
+
+	// Strides of axes with length 1 don't matter.
+	require.True(t, Layout{Strides: []int{100, 1}}.IsRowMajor(Make(dtype.Float32, 1, 5)))
+
+	// Column-major and custom axis orders.
+	columnMajor := ColumnMajorLayout(arrayType)
+	require.Equal(t, []int{1, 2, 6}, columnMajor.Strides)
+	require.True(t, columnMajor.IsColumnMajor(arrayType))
+	require.False(t, columnMajor.IsRowMajor(arrayType))
+	require.False(t, layout.IsColumnMajor(arrayType))
+	custom, err := LayoutFromMinorToMajor(arrayType, []int{2, 1, 0})
+	require.NoError(t, err)
+	require.Equal(t, layout, custom)
+	custom, err = LayoutFromMinorToMajor(arrayType, []int{1, 2, 0})
+	require.NoError(t, err)
+	require.Equal(t, []int{12, 1, 3}, custom.Strides)
+	_, err = LayoutFromMinorToMajor(arrayType, []int{1, 1, 0})
+	require.Error(t, err)
+	_, err = LayoutFromMinorToMajor(arrayType, []int{1, 0})
+	require.Error(t, err)
+
+	// Layouts without one stride per axis.
+	require.False(t, layout.IsRowMajor(Make(dtype.Float32, 2, 3)))
+	require.False(t, layout.IsColumnMajor(Make(dtype.Float32, 2, 3, 4, 5)))
+	require.False(t, Layout{}.IsRowMajor(Make(dtype.Float32, 0, 3)))
+
+	// Scalars have nil strides.
+	scalar := Make(dtype.Float32)
+	scalarLayout, err := LayoutFromMinorToMajor(scalar, nil)
+	require.NoError(t, err)
+	require.Equal(t, RowMajorLayout(scalar), scalarLayout)
+	require.Equal(t, RowMajorLayout(scalar), ColumnMajorLayout(scalar))
+	require.True(t, scalarLayout.IsRowMajor(scalar))
+	require.True(t, scalarLayout.IsColumnMajor(scalar))
+}
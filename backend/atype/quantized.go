@@ -0,0 +1,228 @@
+package atype
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/pkg/errors"
+	"github.com/sebffischer/backend/backend/dtype"
+)
+
+// PerTensor is the value of QuantizedType.Axis for quantization parameters shared by all elements of an array.
+const PerTensor = int(-1)
+
+// QuantizedType describes a linearly (affine) quantized dtype: values are stored in StorageDType
+// (an integer dtype) and represent real values of ExpressedDType (a float dtype) given by
+//
+//	real = scale * (stored - zeroPoint)
+//
+// Quantization can be per-tensor (Axis == PerTensor, one scale and zero-point) or per-axis, in which case
+// there is one scale and zero-point for each index along Axis.
+//
+// Use NewQuantizedType or NewPerAxisQuantizedType to create one, and MakeQuantized to create an ArrayType that carries it.
+type QuantizedType struct {
+	// StorageDType is the integer dtype used to store the quantized values.
+	StorageDType dtype.DType
+
+	// ExpressedDType is the float dtype of the values represented.
+	ExpressedDType dtype.DType
+
+	// Axis along which the quantization parameters vary, or PerTensor.
+	Axis int
+
+	// Scales holds one scale for per-tensor quantization, or one per index along Axis.
+	Scales []float64
+
+	// ZeroPoints has the same length as Scales.
+	ZeroPoints []int64
+}
+
+// NewQuantizedType returns a per-tensor QuantizedType.
+func NewQuantizedType(storage, expressed dtype.DType, scale float64, zeroPoint int64) (QuantizedType, error) {
+	qt := QuantizedType{
+		StorageDType:   storage,
+		ExpressedDType: expressed,
+		Axis:           PerTensor,
+		Scales:         []float64{scale},
+		ZeroPoints:     []int64{zeroPoint},
+	}
+	return qt, qt.Validate()
+}
+
+// NewPerAxisQuantizedType returns a per-axis QuantizedType, with one scale and zero-point per index along axis.
+func NewPerAxisQuantizedType(storage, expressed dtype.DType, axis int, scales []float64, zeroPoints []int64) (QuantizedType, error) {
+	qt := QuantizedType{
+		StorageDType:   storage,
+		ExpressedDType: expressed,
+		Axis:           axis,
+		Scales:         slices.Clone(scales),
+		ZeroPoints:     slices.Clone(zeroPoints),
+	}
+	return qt, qt.Validate()
+}
+
+// Validate returns an error if the quantization parameters are inconsistent: storage must be an integer dtype,
+// expressed must be a float dtype, scales must be positive and finite, and zero-points must be representable in storage.
+func (qt QuantizedType) Validate() error {
+	lowest, highest, ok := quantizedStorageRange(qt.StorageDType)
+	if !ok {
+		return errors.Errorf("QuantizedType: storage dtype %s is not a supported integer dtype", qt.StorageDType)
+	}
+	if !qt.ExpressedDType.IsFloat() {
+		return errors.Errorf("QuantizedType: expressed dtype %s is not a float dtype", qt.ExpressedDType)
+	}
+	if qt.Axis < PerTensor {
+		return errors.Errorf("QuantizedType: invalid axis %d", qt.Axis)
+	}
+	if len(qt.Scales) == 0 || len(qt.Scales) != len(qt.ZeroPoints) {
+		return errors.Errorf("QuantizedType: got %d scales and %d zero-points, they must be the same and > 0", len(qt.Scales), len(qt.ZeroPoints))
+	}
+	if qt.Axis == PerTensor && len(qt.Scales) != 1 {
+		return errors.Errorf("QuantizedType: per-tensor quantization requires exactly one scale, got %d", len(qt.Scales))
+	}
+	for ii, scale := range qt.Scales {
+		if !(scale > 0) || math.IsInf(scale, 0) {
+			return errors.Errorf("QuantizedType: scale #%d is %g, it must be positive and finite", ii, scale)
+		}
+		if zp := qt.ZeroPoints[ii]; zp < lowest || zp > highest {
+			return errors.Errorf("QuantizedType: zero-point #%d is %d, out of range [%d, %d] of %s", ii, zp, lowest, highest, qt.StorageDType)
+		}
+	}
+	return nil
+}
+
+// IsPerAxis returns whether the quantization parameters vary along an axis.
+func (qt QuantizedType) IsPerAxis() bool { return qt.Axis != PerTensor }
+
+// Equal compares two quantized types for equality.
+func (qt QuantizedType) Equal(other QuantizedType) bool {
+	return qt.StorageDType == other.StorageDType && qt.ExpressedDType == other.ExpressedDType && qt.Axis == other.Axis &&
+		slices.Equal(qt.Scales, other.Scales) && slices.Equal(qt.ZeroPoints, other.ZeroPoints)
+}
+
+// Clone returns a deep copy of the quantized type.
+func (qt QuantizedType) Clone() QuantizedType {
+	qt.Scales = slices.Clone(qt.Scales)
+	qt.ZeroPoints = slices.Clone(qt.ZeroPoints)
+	return qt
+}
+
+// String implements fmt.Stringer.
+func (qt QuantizedType) String() string {
+	if qt.IsPerAxis() {
+		return fmt.Sprintf("!quant<%s:%s, axis=%d, scales=%v, zero_points=%v>", qt.StorageDType, qt.ExpressedDType, qt.Axis, qt.Scales, qt.ZeroPoints)
+	}
+	return fmt.Sprintf("!quant<%s:%s, scale=%g, zero_point=%d>", qt.StorageDType, qt.ExpressedDType, qt.Scales[0], qt.ZeroPoints[0])
+}
+
+// Quantize converts the real value x to its stored representation, rounding to the nearest integer (ties to even)
+// and saturating to the range of StorageDType.
+//
+// axisIndex is the index along Axis for per-axis quantization, and it is ignored for per-tensor quantization.
+// It panics if axisIndex is out-of-bounds, see QuantizeE for a version that returns an error.
+func (qt QuantizedType) Quantize(x float64, axisIndex int) int64 {
+	q, err := qt.QuantizeE(x, axisIndex)
+	if err != nil {
+		panic(err)
+	}
+	return q
+}
+
+// QuantizeE is like Quantize, but returns an error if axisIndex is out-of-bounds for the scales.
+func (qt QuantizedType) QuantizeE(x float64, axisIndex int) (int64, error) {
+	scale, zeroPoint, err := qt.params(axisIndex)
+	if err != nil {
+		return 0, errors.WithMessage(err, "QuantizedType.Quantize")
+	}
+	lowest, highest, _ := quantizedStorageRange(qt.StorageDType)
+	q := math.RoundToEven(x/scale) + float64(zeroPoint)
+	if math.IsNaN(q) {
+		return zeroPoint, nil
+	}
+	return int64(max(float64(lowest), min(float64(highest), q))), nil
+}
+
+// Dequantize converts the stored value q back to its real value.
+//
+// axisIndex is the index along Axis for per-axis quantization, and it is ignored for per-tensor quantization.
+// It panics if axisIndex is out-of-bounds, see DequantizeE for a version that returns an error.
+func (qt QuantizedType) Dequantize(q int64, axisIndex int) float64 {
+	x, err := qt.DequantizeE(q, axisIndex)
+	if err != nil {
+		panic(err)
+	}
+	return x
+}
+
+// DequantizeE is like Dequantize, but returns an error if axisIndex is out-of-bounds for the scales.
+func (qt QuantizedType) DequantizeE(q int64, axisIndex int) (float64, error) {
+	scale, zeroPoint, err := qt.params(axisIndex)
+	if err != nil {
+		return 0, errors.WithMessage(err, "QuantizedType.Dequantize")
+	}
+	return scale * float64(q-zeroPoint), nil
+}
+
+// params returns the scale and zero-point for axisIndex, or an error if it is out-of-bounds.
+func (qt QuantizedType) params(axisIndex int) (scale float64, zeroPoint int64, err error) {
+	if !qt.IsPerAxis() {
+		axisIndex = 0
+	}
+	if axisIndex < 0 || axisIndex >= len(qt.Scales) || axisIndex >= len(qt.ZeroPoints) {
+		return 0, 0, errors.Errorf("axis index %d out-of-bounds for %s with %d scales and %d zero-points",
+			axisIndex, qt.StorageDType, len(qt.Scales), len(qt.ZeroPoints))
+	}
+	return qt.Scales[axisIndex], qt.ZeroPoints[axisIndex], nil
+}
+
+// quantizedStorageRange returns the range of values representable by the storage dtype, and false if the dtype
+// can't be used as quantized storage.
+func quantizedStorageRange(dt dtype.DType) (lowest, highest int64, ok bool) {
+	switch dt {
+	case dtype.Int8:
+		return math.MinInt8, math.MaxInt8, true
+	case dtype.Int16:
+		return math.MinInt16, math.MaxInt16, true
+	case dtype.Int32:
+		return math.MinInt32, math.MaxInt32, true
+	case dtype.Uint8:
+		return 0, math.MaxUint8, true
+	case dtype.Uint16:
+		return 0, math.MaxUint16, true
+	case dtype.S4:
+		return -8, 7, true
+	case dtype.U4:
+		return 0, 15, true
+	}
+	return 0, 0, false
+}
+
+// MakeQuantized returns an ArrayType with dtype qt.StorageDType carrying the quantization parameters qt.
+//
+// It returns an error if qt is not valid, if any axis length is negative, or if, for per-axis quantization,
+// the quantized axis doesn't exist or its length doesn't match the number of scales.
+func MakeQuantized(qt QuantizedType, axisLengths ...int) (ArrayType, error) {
+	if err := qt.Validate(); err != nil {
+		return Invalid(), err
+	}
+	at, err := MakeE(qt.StorageDType, axisLengths...)
+	if err != nil {
+		return Invalid(), err
+	}
+	if qt.IsPerAxis() {
+		if qt.Axis >= at.NumAxes() {
+			return Invalid(), errors.Errorf("atype.MakeQuantized(%s): quantized axis %d out-of-bounds for axis lengths %v", qt, qt.Axis, axisLengths)
+		}
+		if at.AxisLengths[qt.Axis] != len(qt.Scales) {
+			return Invalid(), errors.Errorf("atype.MakeQuantized(%s): quantized axis %d has length %d, but there are %d scales",
+				qt, qt.Axis, at.AxisLengths[qt.Axis], len(qt.Scales))
+		}
+	}
+	qt = qt.Clone()
+	at.Quantization = &qt
+	return at, nil
+}
+
+// IsQuantized returns whether the array type carries quantization parameters.
+func (at ArrayType) IsQuantized() bool { return at.Quantization != nil }
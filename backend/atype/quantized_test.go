@@ -0,0 +1,98 @@
+package atype
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+
+	"github.com/sebffischer/backend/backend/dtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuantizedType(t *testing.T) {
+	qt, err := NewQuantizedType(dtype.Int8, dtype.Float32, 0.5, 3)
+	require.NoError(t, err)
+	require.False(t, qt.IsPerAxis())
+	require.Equal(t, int64(3), qt.Quantize(0, 0))
+	require.Equal(t, int64(5), qt.Quantize(1, 0))
+	require.Equal(t, int64(127), qt.Quantize(1000, 0))   // Saturates.
+	require.Equal(t, int64(-128), qt.Quantize(-1000, 0)) // Saturates.
+	require.Equal(t, 1.0, qt.Dequantize(5, 0))
+
+	// Invalid parameters.
+	_, err = NewQuantizedType(dtype.Float32, dtype.Float32, 0.5, 0)
+	require.Error(t, err)
+	_, err = NewQuantizedType(dtype.Int8, dtype.Int32, 0.5, 0)
+	require.Error(t, err)
+	_, err = NewQuantizedType(dtype.Int8, dtype.Float32, -1, 0)
+	require.Error(t, err)
+	_, err = NewQuantizedType(dtype.U4, dtype.Float32, 1, 16)
+	require.Error(t, err)
+
+	// Per-axis.
+	qt, err = NewPerAxisQuantizedType(dtype.Uint8, dtype.BFloat16, 1, []float64{1, 2}, []int64{0, 10})
+	require.NoError(t, err)
+	require.True(t, qt.IsPerAxis())
+	require.Equal(t, int64(12), qt.Quantize(4, 1))
+	require.Equal(t, 4.0, qt.Dequantize(12, 1))
+	_, err = qt.QuantizeE(4, 3)
+	require.Error(t, err)
+	_, err = qt.DequantizeE(12, -1)
+	require.Error(t, err)
+	require.Panics(t, func() { qt.Quantize(4, 3) })
+	_, err = NewPerAxisQuantizedType(dtype.Uint8, dtype.BFloat16, 1, []float64{1, 2}, []int64{0})
+	require.Error(t, err)
+}
+
+func TestMakeQuantized(t *testing.T) {
+	qt, err := NewPerAxisQuantizedType(dtype.Int8, dtype.Float32, 1, []float64{1, 2, 3}, []int64{0, 0, 0})
+	require.NoError(t, err)
+	at, err := MakeQuantized(qt, 4, 3)
+	require.NoError(t, err)
+	require.True(t, at.IsQuantized())
+	require.Equal(t, dtype.Int8, at.DType)
+	require.False(t, at.Equal(Make(dtype.Int8, 4, 3)))
+	require.True(t, at.Equal(at.Clone()))
+	require.Contains(t, at.String(), "axis=1")
+
+	// Quantized axis length must match the number of scales.
+	_, err = MakeQuantized(qt, 3, 4)
+	require.Error(t, err)
+	_, err = MakeQuantized(qt, 3)
+	require.Error(t, err)
+
+	// Per-axis quantized array types can't be reshaped, bitcast drops the quantization.
+	_, err = Reshape(at, 12)
+	require.Error(t, err)
+	bitcast, err := BitcastConvert(at, dtype.Uint8)
+	require.NoError(t, err)
+	require.False(t, bitcast.IsQuantized())
+
+	// Gob round-trip.
+	buf := &bytes.Buffer{}
+	enc := gob.NewEncoder(buf)
+	require.NoError(t, at.GobSerialize(enc))
+	require.NoError(t, Make(dtype.Float32, 2).GobSerialize(enc))
+	dec := gob.NewDecoder(buf)
+	got, err := GobDeserialize(dec)
+	require.NoError(t, err)
+	require.True(t, at.Equal(got))
+	got, err = GobDeserialize(dec)
+	require.NoError(t, err)
+	require.True(t, got.Equal(Make(dtype.Float32, 2)))
+
+	// Encodings from before quantization was supported (just DType and AxisLengths) still decode, leaving
+	// the data that follows in the stream untouched.
+	buf.Reset()
+	enc = gob.NewEncoder(buf)
+	require.NoError(t, enc.Encode(dtype.Int8))
+	require.NoError(t, enc.Encode([]int{3, 4}))
+	require.NoError(t, enc.Encode("next"))
+	dec = gob.NewDecoder(buf)
+	got, err = GobDeserialize(dec)
+	require.NoError(t, err)
+	require.True(t, got.Equal(Make(dtype.Int8, 3, 4)))
+	var next string
+	require.NoError(t, dec.Decode(&next))
+	require.Equal(t, "next", next)
+}
@@ -0,0 +1,89 @@
+package atype
+
+import (
+	"github.com/pkg/errors"
+	"github.com/sebffischer/backend/backend/dtype"
+)
+
+// BitcastConvert returns the array type resulting from reinterpreting the bits of an array of type at
+// as the dtype target, following the StableHLO bitcast_convert semantics:
+//
+//   - If both dtypes have the same number of bits, the axes are unchanged.
+//   - If target is narrower, a new last axis of length bits(at.DType)/bits(target) is appended.
+//   - If target is wider, the last axis of at must have length bits(target)/bits(at.DType), and it is removed.
+//
+// Sub-byte dtypes (S4, U4, S2, U2) are handled by their bit widths, with the packing rules of dtype.PackSubByte:
+// e.g. reinterpreting Uint8[3] as S4 gives S4[3 2], where [i 0] holds the low 4 bits of element i.
+//
+// It returns an error if any of the dtypes is not supported, or if the bit widths are not multiples of each other.
+// Quantization parameters are dropped, since they don't apply to the reinterpreted bits.
+func BitcastConvert(at ArrayType, target dtype.DType) (ArrayType, error) {
+	at = at.Clone()
+	at.Quantization = nil
+	if !at.Ok() {
+		return Invalid(), errors.Errorf("atype.BitcastConvert(%s, %s): invalid array type", at, target)
+	}
+	if !bitcastable(at.DType) || !bitcastable(target) {
+		return Invalid(), errors.Errorf("atype.BitcastConvert(%s, %s): dtype not supported", at, target)
+	}
+	srcBits, tgtBits := at.DType.Bits(), target.Bits()
+	switch {
+	case srcBits == tgtBits:
+		result := at
+		result.DType = target
+		return result, nil
+
+	case srcBits > tgtBits:
+		if srcBits%tgtBits != 0 {
+			return Invalid(), errors.Errorf("atype.BitcastConvert(%s, %s): %d bits is not a multiple of %d bits", at, target, srcBits, tgtBits)
+		}
+		result := at
+		result.DType = target
+		result.AxisLengths = append(result.AxisLengths, srcBits/tgtBits)
+		return result, nil
+
+	default:
+		if tgtBits%srcBits != 0 {
+			return Invalid(), errors.Errorf("atype.BitcastConvert(%s, %s): %d bits is not a multiple of %d bits", at, target, tgtBits, srcBits)
+		}
+		ratio := tgtBits / srcBits
+		if at.IsScalar() || at.AxisLength(-1) != ratio {
+			return Invalid(), errors.Errorf("atype.BitcastConvert(%s, %s): the last axis must have length %d to be packed into a %s", at, target, ratio, target)
+		}
+		result := at
+		result.DType = target
+		result.AxisLengths = result.AxisLengths[:at.NumAxes()-1]
+		return result, nil
+	}
+}
+
+// bitcastable returns whether the dtype has a well-defined bit representation for BitcastConvert.
+func bitcastable(dt dtype.DType) bool {
+	return dt.IsSupported() || (dt.IsSubByte() && dt.IsInteger())
+}
+
+// Reshape returns the array type with the same dtype as at and the new axis lengths.
+//
+// It returns an error if any axis length is negative or if the total number of elements differs,
+// since a reshape only reinterprets the layout and never adds or drops elements.
+//
+// Per-tensor quantization parameters are preserved, but per-axis quantized array types can't be reshaped,
+// since the quantized axis would be lost.
+func Reshape(at ArrayType, axisLengths ...int) (ArrayType, error) {
+	if !at.Ok() {
+		return Invalid(), errors.Errorf("atype.Reshape(%s, %v): invalid array type", at, axisLengths)
+	}
+	if at.IsQuantized() && at.Quantization.IsPerAxis() {
+		return Invalid(), errors.Errorf("atype.Reshape(%s, %v): cannot reshape per-axis quantized array type", at, axisLengths)
+	}
+	result := ArrayType{DType: at.DType, AxisLengths: axisLengths, Quantization: at.Quantization}.Clone()
+	for _, length := range axisLengths {
+		if length < 0 {
+			return Invalid(), errors.Errorf("atype.Reshape(%s, %v): axis lengths cannot be negative", at, axisLengths)
+		}
+	}
+	if result.Size() != at.Size() {
+		return Invalid(), errors.Errorf("atype.Reshape(%s, %v): number of elements differ (%d != %d)", at, axisLengths, at.Size(), result.Size())
+	}
+	return result, nil
+}
@@ -0,0 +1,97 @@
+package atype
+
+import (
+	"testing"
+
+	"github.com/sebffischer/backend/backend/dtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBitcastConvert(t *testing.T) {
+	// Same bit width: axes are unchanged.
+	got, err := BitcastConvert(Make(dtype.Float32, 2, 3), dtype.Uint32)
+	require.NoError(t, err)
+	require.True(t, got.Equal(Make(dtype.Uint32, 2, 3)))
+
+	// Narrower target: a new last axis is appended.
+	got, err = BitcastConvert(Make(dtype.Float32, 2, 3), dtype.Uint8)
+	require.NoError(t, err)
+	require.True(t, got.Equal(Make(dtype.Uint8, 2, 3, 4)))
+
+	got, err = BitcastConvert(Make(dtype.Float64), dtype.Int16)
+	require.NoError(t, err)
+	require.True(t, got.Equal(Make(dtype.Int16, 4)))
+
+	// Wider target: last axis is consumed.
+	got, err = BitcastConvert(Make(dtype.Uint8, 5, 4), dtype.Float32)
+	require.NoError(t, err)
+	require.True(t, got.Equal(Make(dtype.Float32, 5)))
+
+	// Wider target with wrong last axis length or scalar.
+	_, err = BitcastConvert(Make(dtype.Uint8, 5, 2), dtype.Float32)
+	require.Error(t, err)
+	_, err = BitcastConvert(Make(dtype.Int16), dtype.Int64)
+	require.Error(t, err)
+
+	// Sub-byte dtypes.
+	got, err = BitcastConvert(Make(dtype.Uint8, 3), dtype.S4)
+	require.NoError(t, err)
+	require.True(t, got.Equal(Make(dtype.S4, 3, 2)))
+	got, err = BitcastConvert(Make(dtype.S2, 5, 4), dtype.Int8)
+	require.NoError(t, err)
+	require.True(t, got.Equal(Make(dtype.Int8, 5)))
+	got, err = BitcastConvert(Make(dtype.S4, 7), dtype.U4)
+	require.NoError(t, err)
+	require.True(t, got.Equal(Make(dtype.U4, 7)))
+	got, err = BitcastConvert(Make(dtype.U4, 2), dtype.U2)
+	require.NoError(t, err)
+	require.True(t, got.Equal(Make(dtype.U2, 2, 2)))
+	_, err = BitcastConvert(Make(dtype.S4, 3), dtype.Int8)
+	require.Error(t, err)
+
+	// Invalid array type.
+	_, err = BitcastConvert(Invalid(), dtype.Int64)
+	require.Error(t, err)
+}
+
+func TestReshape(t *testing.T) {
+	got, err := Reshape(Make(dtype.Int32, 2, 3), 3, 2)
+	require.NoError(t, err)
+	require.True(t, got.Equal(Make(dtype.Int32, 3, 2)))
+
+	got, err = Reshape(Make(dtype.Int32, 1, 1))
+	require.NoError(t, err)
+	require.True(t, got.IsScalar())
+
+	_, err = Reshape(Make(dtype.Int32, 2, 3), 4, 2)
+	require.Error(t, err)
+	_, err = Reshape(Make(dtype.Int32, 0), -1, 0)
+	require.Error(t, err)
+}
+
+func FuzzBitcastConvertReshape(f *testing.F) {
+	f.Add(uint8(dtype.Float32), uint8(dtype.Uint8), 2, 3, 6)
+	f.Add(uint8(dtype.Uint8), uint8(dtype.S4), 3, 1, 3)
+	f.Add(uint8(dtype.Int16), uint8(dtype.Int64), 5, 4, 20)
+	f.Fuzz(func(t *testing.T, src, target uint8, dim0, dim1, reshaped int) {
+		if dim0 < 0 || dim1 < 0 || dim0 > 1<<10 || dim1 > 1<<10 {
+			return
+		}
+		at, err := MakeE(dtype.DType(src), dim0, dim1)
+		if err != nil {
+			return
+		}
+		got, err := BitcastConvert(at, dtype.DType(target))
+		if err == nil {
+			// The number of bits must be preserved, and converting back must give the original array type.
+			require.Equal(t, at.Size()*at.DType.Bits(), got.Size()*got.DType.Bits(), "%s -> %s", at, got)
+			back, err := BitcastConvert(got, at.DType)
+			require.NoError(t, err)
+			require.True(t, back.Equal(at), "%s -> %s -> %s", at, got, back)
+		}
+		got, err = Reshape(at, reshaped)
+		if err == nil {
+			require.Equal(t, at.Size(), got.Size())
+		}
+	})
+}
@@ -20,6 +20,7 @@ import (
 	"testing"
 
 	"github.com/sebffischer/backend/backend/dtype"
+	"github.com/sebffischer/backend/backend/dtype/float8"
 	"github.com/stretchr/testify/require"
 )
 
@@ -35,6 +36,12 @@ func TestCastAsDType(t *testing.T) {
 		got := CastAsDType(value, dtype.Complex64)
 		require.Equal(t, want, got)
 	}
+	{
+		want := [][]float8.E4M3FN{{0x38, 0x40}, {0x44, 0x48}, {0x4A, 0x4C}}
+		got := CastAsDType(value, dtype.F8E4M3FN)
+		require.Equal(t, want, got)
+		require.Equal(t, float32(6), ConvertTo[float32](got.([][]float8.E4M3FN)[2][1]))
+	}
 }
 
 func TestArrayType(t *testing.T) {
@@ -56,6 +63,11 @@ func TestArrayType(t *testing.T) {
 	require.Len(t, arrayType1.AxisLengths, 3)
 	require.Equal(t, 4*3*2, arrayType1.Size())
 	require.Equal(t, 4*4*3*2, int(arrayType1.Memory()))
+
+	// Sub-byte dtypes are bit-packed.
+	require.Equal(t, 3, int(Make(dtype.S4, 5).Memory()))
+	require.Equal(t, 2, int(Make(dtype.U2, 2, 3).Memory()))
+	require.Equal(t, 1, int(Make(dtype.S2).Memory()))
 }
 
 func TestAxisLength(t *testing.T) {
@@ -68,6 +80,23 @@ func TestAxisLength(t *testing.T) {
 	require.Equal(t, 2, arrayType.AxisLength(-1))
 	require.Panics(t, func() { _ = arrayType.AxisLength(3) })
 	require.Panics(t, func() { _ = arrayType.AxisLength(-4) })
+
+	length, err := arrayType.AxisLengthE(-1)
+	require.NoError(t, err)
+	require.Equal(t, 2, length)
+	_, err = arrayType.AxisLengthE(3)
+	require.Error(t, err)
+}
+
+func TestMakeE(t *testing.T) {
+	arrayType, err := MakeE(dtype.Int8, 2, 0)
+	require.NoError(t, err)
+	require.True(t, arrayType.Equal(Make(dtype.Int8, 2, 0)))
+
+	arrayType, err = MakeE(dtype.Int8, 2, -1)
+	require.ErrorContains(t, err, "atype.MakeE(")
+	require.False(t, arrayType.Ok())
+	require.Panics(t, func() { _ = Make(dtype.Int8, 2, -1) })
 }
 
 func TestFromAnyValue(t *testing.T) {
@@ -0,0 +1,138 @@
+package atype
+
+import (
+	"github.com/pkg/errors"
+)
+
+// WindowConfig describes a sliding window over the axes of an array, as used by the StableHLO reduce_window
+// operation and by pooling layers. Each field holds one value per axis of the operand.
+type WindowConfig struct {
+	// Dimensions is the length of the window on each axis. Use 1 for axes that are not pooled (e.g. batch).
+	Dimensions []int
+
+	// Strides of the window on each axis. If nil, it defaults to 1 for all axes.
+	Strides []int
+
+	// BaseDilations of the operand on each axis: a dilation d inserts d-1 holes between elements.
+	// If nil, it defaults to 1 (no dilation) for all axes.
+	BaseDilations []int
+
+	// WindowDilations on each axis: a dilation d inserts d-1 holes between the elements of the window.
+	// If nil, it defaults to 1 (no dilation) for all axes.
+	WindowDilations []int
+
+	// Paddings holds the low and high padding of each axis. If nil, there is no padding.
+	Paddings [][2]int
+}
+
+// ReduceWindow returns the array type resulting from reducing an operand of type at over the sliding windows
+// described by config, following the StableHLO reduce_window semantics. The dtype is preserved.
+//
+// For each axis, the output length is floor((paddedLength - dilatedWindow) / stride) + 1, or 0 if the
+// dilated window is larger than the padded (and dilated) operand.
+//
+// It returns an error if the configuration doesn't have one value per axis, or if any value is out of range.
+func ReduceWindow(at ArrayType, config WindowConfig) (ArrayType, error) {
+	if !at.Ok() {
+		return Invalid(), errors.Errorf("atype.ReduceWindow(%s): invalid array type", at)
+	}
+	numAxes := at.NumAxes()
+	if len(config.Dimensions) != numAxes {
+		return Invalid(), errors.Errorf("atype.ReduceWindow(%s): got %d window dimensions, wanted one per axis", at, len(config.Dimensions))
+	}
+	for _, field := range []struct {
+		name   string
+		length int
+		isSet  bool
+	}{
+		{"strides", len(config.Strides), config.Strides != nil},
+		{"base dilations", len(config.BaseDilations), config.BaseDilations != nil},
+		{"window dilations", len(config.WindowDilations), config.WindowDilations != nil},
+		{"paddings", len(config.Paddings), config.Paddings != nil},
+	} {
+		if field.isSet && field.length != numAxes {
+			return Invalid(), errors.Errorf("atype.ReduceWindow(%s): got %d %s, wanted one per axis", at, field.length, field.name)
+		}
+	}
+
+	result := at.Clone()
+	result.Quantization = nil
+	for axis, length := range at.AxisLengths {
+		window, stride, baseDilation, windowDilation := config.Dimensions[axis], 1, 1, 1
+		var padding [2]int
+		if config.Strides != nil {
+			stride = config.Strides[axis]
+		}
+		if config.BaseDilations != nil {
+			baseDilation = config.BaseDilations[axis]
+		}
+		if config.WindowDilations != nil {
+			windowDilation = config.WindowDilations[axis]
+		}
+		if config.Paddings != nil {
+			padding = config.Paddings[axis]
+		}
+		if window < 1 || stride < 1 || baseDilation < 1 || windowDilation < 1 {
+			return Invalid(), errors.Errorf("atype.ReduceWindow(%s): axis %d has window %d, stride %d, base dilation %d and window dilation %d, all must be >= 1",
+				at, axis, window, stride, baseDilation, windowDilation)
+		}
+		if padding[0] < 0 || padding[1] < 0 {
+			return Invalid(), errors.Errorf("atype.ReduceWindow(%s): axis %d has negative padding %v", at, axis, padding)
+		}
+		padded := padding[0] + padding[1]
+		if length > 0 {
+			padded += (length-1)*baseDilation + 1
+		}
+		dilatedWindow := (window-1)*windowDilation + 1
+		if padded < dilatedWindow {
+			result.AxisLengths[axis] = 0
+		} else {
+			result.AxisLengths[axis] = (padded-dilatedWindow)/stride + 1
+		}
+	}
+	return result, nil
+}
+
+// SamePaddings returns the paddings for the given window dimensions and strides (nil for 1) such that each axis
+// of the output has length ceil(length/stride), the "SAME" padding of pooling and convolution layers.
+// When the total padding of an axis is odd, the extra element is added to the high side.
+func SamePaddings(at ArrayType, dimensions, strides []int) ([][2]int, error) {
+	if !at.Ok() {
+		return nil, errors.Errorf("atype.SamePaddings(%s): invalid array type", at)
+	}
+	if len(dimensions) != at.NumAxes() || (strides != nil && len(strides) != at.NumAxes()) {
+		return nil, errors.Errorf("atype.SamePaddings(%s): got %d window dimensions and %d strides, wanted one per axis",
+			at, len(dimensions), len(strides))
+	}
+	paddings := make([][2]int, at.NumAxes())
+	for axis, length := range at.AxisLengths {
+		window, stride := dimensions[axis], 1
+		if strides != nil {
+			stride = strides[axis]
+		}
+		if window < 1 || stride < 1 {
+			return nil, errors.Errorf("atype.SamePaddings(%s): axis %d has window %d and stride %d, both must be >= 1", at, axis, window, stride)
+		}
+		outputLength := (length + stride - 1) / stride
+		total := max((outputLength-1)*stride+window-length, 0)
+		paddings[axis] = [2]int{total / 2, total - total/2}
+	}
+	return paddings, nil
+}
+
+// Pool returns the array type of a max or average pooling of the operand at, with the given window dimensions
+// and strides (nil for 1) on each axis. If samePadding is true, the operand is padded as described in
+// SamePaddings, otherwise no padding is used ("VALID" padding).
+//
+// It is a convenience wrapper around ReduceWindow.
+func Pool(at ArrayType, dimensions, strides []int, samePadding bool) (ArrayType, error) {
+	config := WindowConfig{Dimensions: dimensions, Strides: strides}
+	if samePadding {
+		paddings, err := SamePaddings(at, dimensions, strides)
+		if err != nil {
+			return Invalid(), err
+		}
+		config.Paddings = paddings
+	}
+	return ReduceWindow(at, config)
+}
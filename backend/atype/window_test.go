@@ -0,0 +1,93 @@
+package atype
+
+import (
+	"testing"
+
+	"github.com/sebffischer/backend/backend/dtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReduceWindow(t *testing.T) {
+	// 2x2 max-pool with stride 2 over an NHWC image.
+	got, err := ReduceWindow(Make(dtype.Float32, 8, 32, 32, 3), WindowConfig{
+		Dimensions: []int{1, 2, 2, 1},
+		Strides:    []int{1, 2, 2, 1},
+	})
+	require.NoError(t, err)
+	require.True(t, got.Equal(Make(dtype.Float32, 8, 16, 16, 3)), "got %s", got)
+
+	// Odd lengths are floored, and windows larger than the operand give 0.
+	got, err = ReduceWindow(Make(dtype.Int32, 7, 2), WindowConfig{Dimensions: []int{3, 3}, Strides: []int{2, 1}})
+	require.NoError(t, err)
+	require.True(t, got.Equal(Make(dtype.Int32, 3, 0)), "got %s", got)
+
+	// Padding and dilations.
+	got, err = ReduceWindow(Make(dtype.Float64, 5), WindowConfig{
+		Dimensions:      []int{3},
+		Paddings:        [][2]int{{1, 2}},
+		BaseDilations:   []int{2}, // 9 elements, 12 padded.
+		WindowDilations: []int{2}, // Window of 5.
+	})
+	require.NoError(t, err)
+	require.True(t, got.Equal(Make(dtype.Float64, 8)), "got %s", got)
+
+	// Errors.
+	_, err = ReduceWindow(Make(dtype.Float32, 4, 4), WindowConfig{Dimensions: []int{2}})
+	require.Error(t, err)
+	_, err = ReduceWindow(Make(dtype.Float32, 4), WindowConfig{Dimensions: []int{2}, Strides: []int{1, 1}})
+	require.ErrorContains(t, err, "strides")
+	_, err = ReduceWindow(Make(dtype.Float32, 4), WindowConfig{Dimensions: []int{2}, Strides: []int{0}})
+	require.Error(t, err)
+	_, err = ReduceWindow(Make(dtype.Float32, 4), WindowConfig{Dimensions: []int{2}, Paddings: [][2]int{{-1, 0}}})
+	require.Error(t, err)
+	_, err = ReduceWindow(Invalid(), WindowConfig{})
+	require.Error(t, err)
+}
+
+func TestPool(t *testing.T) {
+	paddings, err := SamePaddings(Make(dtype.Float32, 5, 6), []int{3, 2}, []int{2, 2})
+	require.NoError(t, err)
+	require.Equal(t, [][2]int{{1, 1}, {0, 0}}, paddings)
+
+	paddings, err = SamePaddings(Make(dtype.Float32, 4), []int{4}, nil)
+	require.NoError(t, err)
+	require.Equal(t, [][2]int{{1, 2}}, paddings)
+
+	got, err := Pool(Make(dtype.Float32, 1, 5, 6, 3), []int{1, 3, 3, 1}, []int{1, 2, 2, 1}, true)
+	require.NoError(t, err)
+	require.True(t, got.Equal(Make(dtype.Float32, 1, 3, 3, 3)), "got %s", got)
+
+	got, err = Pool(Make(dtype.Float32, 1, 5, 6, 3), []int{1, 3, 3, 1}, []int{1, 2, 2, 1}, false)
+	require.NoError(t, err)
+	require.True(t, got.Equal(Make(dtype.Float32, 1, 2, 2, 3)), "got %s", got)
+
+	_, err = Pool(Make(dtype.Float32, 5), []int{3, 3}, nil, true)
+	require.Error(t, err)
+}
+
+func FuzzReduceWindow(f *testing.F) {
+	f.Add(32, 2, 2, 0, 0, 1, 1, true)
+	f.Add(5, 3, 1, 1, 2, 2, 2, false)
+	f.Fuzz(func(t *testing.T, length, window, stride, padLow, padHigh, baseDilation, windowDilation int, same bool) {
+		if length < 0 || length > 1<<16 || window > 1<<16 || stride > 1<<16 || padLow > 1<<16 || padHigh > 1<<16 ||
+			baseDilation > 1<<8 || windowDilation > 1<<8 {
+			return
+		}
+		at := Make(dtype.Float32, length)
+		got, err := ReduceWindow(at, WindowConfig{
+			Dimensions:      []int{window},
+			Strides:         []int{stride},
+			Paddings:        [][2]int{{padLow, padHigh}},
+			BaseDilations:   []int{baseDilation},
+			WindowDilations: []int{windowDilation},
+		})
+		if err == nil {
+			require.GreaterOrEqual(t, got.AxisLength(0), 0)
+			require.Equal(t, dtype.Float32, got.DType)
+		}
+		got, err = Pool(at, []int{window}, []int{stride}, same)
+		if err == nil && same {
+			require.Equal(t, (length+stride-1)/stride, got.AxisLength(0))
+		}
+	})
+}
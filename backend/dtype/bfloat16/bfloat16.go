@@ -31,6 +31,31 @@ func FromFloat32(x float32) BFloat16 {
 	return BFloat16(math.Float32bits(x) >> 16)
 }
 
+// FromFloat32Stochastic converts a float32 to a BFloat16 using stochastic rounding: x is rounded to one of
+// the two nearest representable values, with probabilities proportional to their proximity, so the rounding
+// is unbiased on average. This matters for low-precision training, where small updates would otherwise
+// always be truncated away.
+//
+// random must be uniformly distributed (e.g. from math/rand/v2.Uint32), only its lower 16 bits are used.
+// A value of 0 always rounds towards zero, like FromFloat32. Finite values are never rounded up to infinity.
+func FromFloat32Stochastic(x float32, random uint32) BFloat16 {
+	bits := math.Float32bits(x)
+	if x != x || bits&0x7F800000 == 0x7F800000 {
+		// NaN or infinity: truncate, keeping NaNs as NaNs.
+		if bits&0x7FFFFF != 0 {
+			bits |= 0x400000
+		}
+		return BFloat16(bits >> 16)
+	}
+	// Adding random lower bits carries into the upper 16 bits with probability (lower bits)/2^16.
+	result := BFloat16((bits + random&0xFFFF) >> 16)
+	if result&0x7FFF == 0x7F80 {
+		// A finite value can't round to infinity: keep the largest finite value, with the sign.
+		result--
+	}
+	return result
+}
+
 // FromFloat64 converts a float32 to a BFloat16.
 func FromFloat64(x float64) BFloat16 {
 	return FromFloat32(float32(x))
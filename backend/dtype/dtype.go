@@ -12,6 +12,7 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/sebffischer/backend/backend/dtype/bfloat16"
+	"github.com/sebffischer/backend/backend/dtype/float8"
 	"github.com/x448/float16"
 )
 
@@ -59,6 +60,10 @@ func FromGenericsType[T Supported]() DType {
 		return Float16
 	case bfloat16.BFloat16:
 		return BFloat16
+	case float8.E4M3FN:
+		return F8E4M3FN
+	case float8.E5M2:
+		return F8E5M2
 	case int:
 		switch strconv.IntSize {
 		case 32:
@@ -102,6 +107,10 @@ func FromGoType(t reflect.Type) DType {
 		return Float16
 	case bfloat16Type:
 		return BFloat16
+	case f8e4m3fnType:
+		return F8E4M3FN
+	case f8e5m2Type:
+		return F8E5M2
 	}
 	switch t.Kind() {
 	case reflect.Int:
@@ -155,20 +164,78 @@ func FromAny(value any) DType {
 	return FromGoType(reflect.TypeOf(value))
 }
 
+// dtypeBits holds the number of bits of one element of each declared dtype. Bits, Bytes, Size and IsSubByte
+// are derived from it.
+var dtypeBits = [...]int{
+	InvalidDType:  0,
+	Bool:          8,
+	Int8:          8,
+	Int16:         16,
+	Int32:         32,
+	Int64:         64,
+	Uint8:         8,
+	Uint16:        16,
+	Uint32:        32,
+	Uint64:        64,
+	Float16:       16,
+	Float32:       32,
+	Float64:       64,
+	BFloat16:      16,
+	Complex64:     64,
+	Complex128:    128,
+	F8E5M2:        8,
+	F8E4M3FN:      8,
+	F8E4M3B11FNUZ: 8,
+	F8E5M2FNUZ:    8,
+	F8E4M3FNUZ:    8,
+	S4:            4,
+	U4:            4,
+	S2:            2,
+	U2:            2,
+	F8E4M3:        8,
+	F8E3M4:        8,
+	F8E8M0FNU:     8,
+	F4E2M1FN:      4,
+	F6E3M2FN:      6,
+	F6E2M3FN:      6,
+}
+
 // Size returns the number of bytes for the given DType, or 0 if the dtype uses fraction(s) of bytes.
-// If the size is 0 (like a 4-bits quantity), consider the Bits or SizeForDimensions method.
+// If the size is 0 (like a 4-bits quantity), consider the Bits or SizeForAxes method.
 func (dtype DType) Size() int {
-	return int(dtype.GoType().Size())
+	if dtype.IsSubByte() {
+		return 0
+	}
+	return dtype.Bits() / 8
 }
 
-// Bits returns the number of bits for the given DType.
+// Bits returns the number of bits for the given DType, or 0 for InvalidDType and undeclared values.
 func (dtype DType) Bits() int {
-	return dtype.Size() * 8
+	if dtype < 0 || int(dtype) >= len(dtypeBits) {
+		return 0
+	}
+	return dtypeBits[dtype]
+}
+
+// subByteBits returns the number of bits of dtypes that use less than a byte per element, or 0 for other dtypes.
+func (dtype DType) subByteBits() int {
+	if !dtype.IsSubByte() {
+		return 0
+	}
+	return dtype.Bits()
+}
+
+// isSubByteInteger returns whether dtype is one of the sub-byte integers (S4, U4, S2, U2).
+func (dtype DType) isSubByteInteger() bool {
+	return dtype == S4 || dtype == U4 || dtype == S2 || dtype == U2
 }
 
 // SizeForAxes returns the size in bytes used for the given axes.
 // This is a safer method than Size in case the dtype uses an underlying size that is not multiple of 8 bits.
 //
+// Sub-byte dtypes (see IsSubByte) are bit-packed in row-major order, see PackSubByte, and the size is
+// rounded up to a whole number of bytes.
+//
 // It works also for scalar (one element) shapes where the list of axes is empty.
 func (dtype DType) SizeForAxes(axes ...int) int {
 	numElements := 1
@@ -180,6 +247,9 @@ func (dtype DType) SizeForAxes(axes ...int) int {
 	}
 
 	// Switch case for dtypes with size not multiple of 8 bits (1 byte).
+	if bits := dtype.subByteBits(); bits > 0 {
+		return (numElements*bits + 7) / 8
+	}
 
 	// Default is simply the number of elements times the size in bytes per element.
 	return numElements * dtype.Size()
@@ -197,6 +267,8 @@ var (
 	float64Type  = reflect.TypeOf(float64(0))
 	float16Type  = reflect.TypeOf(float16.Float16(0))
 	bfloat16Type = reflect.TypeOf(bfloat16.BFloat16(0))
+	f8e4m3fnType = reflect.TypeOf(float8.E4M3FN(0))
+	f8e5m2Type   = reflect.TypeOf(float8.E5M2(0))
 )
 
 // GoType returns the Go `reflect.Type` corresponding to the tensor DType.
@@ -227,6 +299,10 @@ func (dtype DType) GoType() reflect.Type {
 		return float16Type
 	case BFloat16:
 		return bfloat16Type
+	case F8E4M3FN:
+		return f8e4m3fnType
+	case F8E5M2:
+		return f8e5m2Type
 	case Float32:
 		return float32Type
 	case Float64:
@@ -254,6 +330,9 @@ func (dtype DType) GoStr() string {
 // LowestValue for dtype converted to the corresponding Go type.
 // For float values it will return negative infinite.
 // There is no lowest value for complex numbers, since they are not ordered.
+//
+// Sub-byte integers (S4, U4, S2, U2) return an int8, the unpacked type used by PackSubByte, and
+// dtypes without a Go type (e.g. F8E4M3 or InvalidDType) return nil.
 func (dtype DType) LowestValue() any {
 	switch dtype {
 	case Int64:
@@ -263,7 +342,7 @@ func (dtype DType) LowestValue() any {
 	case Int16:
 		return int16(math.MinInt16)
 	case Int8:
-		return int16(math.MinInt8)
+		return int8(math.MinInt8)
 
 	case Uint64:
 		return uint64(0)
@@ -274,6 +353,13 @@ func (dtype DType) LowestValue() any {
 	case Uint8:
 		return uint8(0)
 
+	case S4:
+		return int8(-8)
+	case U4, U2:
+		return int8(0)
+	case S2:
+		return int8(-2)
+
 	case Bool:
 		return false
 
@@ -285,16 +371,23 @@ func (dtype DType) LowestValue() any {
 		return float16.Inf(-1)
 	case BFloat16:
 		return bfloat16.Inf(-1)
+	case F8E5M2:
+		return float8.E5M2Inf(-1)
+	case F8E4M3FN:
+		// F8E4M3FN has no infinities, so we return the lowest finite value.
+		return float8.E4M3FNFromFloat32(-448)
 
 	default:
 		// For invalid dtypes (like complex numbers), return zero.
-		return reflect.New(dtype.GoType()).Elem().Interface()
+		return dtype.zeroValue()
 	}
 }
 
 // HighestValue for dtype converted to the corresponding Go type.
 // For float values it will return infinite.
 // There is no lowest value for complex numbers, since they are not ordered.
+//
+// Like LowestValue, sub-byte integers return an int8 and dtypes without a Go type return nil.
 func (dtype DType) HighestValue() any {
 	switch dtype {
 	case Int64:
@@ -315,6 +408,15 @@ func (dtype DType) HighestValue() any {
 	case Uint8:
 		return uint8(math.MaxUint8)
 
+	case S4:
+		return int8(7)
+	case U4:
+		return int8(15)
+	case S2:
+		return int8(1)
+	case U2:
+		return int8(3)
+
 	case Bool:
 		return true
 
@@ -326,18 +428,161 @@ func (dtype DType) HighestValue() any {
 		return float16.Inf(1)
 	case BFloat16:
 		return bfloat16.Inf(1)
+	case F8E5M2:
+		return float8.E5M2Inf(1)
+	case F8E4M3FN:
+		// F8E4M3FN has no infinities, so we return the highest finite value.
+		return float8.E4M3FNFromFloat32(448)
 
 	default:
 		// For invalid dtypes (like complex numbers), return zero.
-		return reflect.New(dtype.GoType()).Elem().Interface()
+		return dtype.zeroValue()
+	}
+}
+
+// zeroValue returns the zero value of the Go type of dtype: int8(0) for sub-byte integers, the unpacked
+// type used by PackSubByte, and nil for dtypes without a Go type (e.g. F8E4M3 or InvalidDType).
+func (dtype DType) zeroValue() any {
+	switch {
+	case dtype.isSubByteInteger():
+		return int8(0)
+	case dtype.IsSupported():
+		return reflect.Zero(dtype.GoType()).Interface()
+	default:
+		return nil
 	}
 }
 
 // SmallestNonZeroValueForDType is the smallest non-zero-value dtypes.
-// Only useful for float types.
-// The return value is converted to the corresponding Go type.
-// There is no smallest non-zero value for complex numbers, since they are not ordered.
+//
+// Deprecated: use SmallestNonZero, which returns the same value.
 func (dtype DType) SmallestNonZeroValueForDType() any {
+	return dtype.SmallestNonZero()
+}
+
+// Lowest returns the lowest finite value for dtype, converted to the corresponding Go type.
+// Unlike LowestValue, for float dtypes it returns the negative of the largest finite value, instead of
+// negative infinity: e.g. -math.MaxFloat32 for Float32.
+// There is no lowest value for complex numbers, since they are not ordered, and zero is returned.
+// Sub-byte integers return an int8 and dtypes without a Go type (e.g. F8E4M3) return nil, see LowestValue.
+func (dtype DType) Lowest() any {
+	if !dtype.IsFloat() {
+		return dtype.LowestValue()
+	}
+	return dtype.floatValue(-dtype.highestFinite())
+}
+
+// Highest returns the highest finite value for dtype, converted to the corresponding Go type.
+// Unlike HighestValue, for float dtypes it returns the largest finite value instead of infinity:
+// e.g. math.MaxFloat32 for Float32 or 448 for F8E4M3FN.
+// There is no highest value for complex numbers, since they are not ordered, and zero is returned.
+// Sub-byte integers return an int8 and dtypes without a Go type (e.g. F8E4M3) return nil, see HighestValue.
+func (dtype DType) Highest() any {
+	if !dtype.IsFloat() {
+		return dtype.HighestValue()
+	}
+	return dtype.floatValue(dtype.highestFinite())
+}
+
+// highestFinite returns the largest finite value of a float dtype as a float64.
+func (dtype DType) highestFinite() float64 {
+	switch dtype {
+	case Float32:
+		return math.MaxFloat32
+	case Float64:
+		return math.MaxFloat64
+	case Float16:
+		return float64(float16.Frombits(0x7BFF).Float32()) // 65504
+	case BFloat16:
+		return float64(bfloat16.FromBits(0x7F7F).Float32()) // ~3.39e38
+	case F8E4M3FN:
+		return 448
+	case F8E5M2:
+		return 57344
+	default:
+		return 0
+	}
+}
+
+// floatValue converts v to the Go type of the float dtype.
+func (dtype DType) floatValue(v float64) any {
+	switch dtype {
+	case Float32:
+		return float32(v)
+	case Float64:
+		return v
+	case Float16:
+		return float16.Fromfloat32(float32(v))
+	case BFloat16:
+		return bfloat16.FromFloat64(v)
+	case F8E4M3FN:
+		return float8.E4M3FNFromFloat64(v)
+	case F8E5M2:
+		return float8.E5M2FromFloat64(v)
+	default:
+		return dtype.zeroValue()
+	}
+}
+
+// Epsilon returns the difference between 1.0 and the next representable value for float dtypes (2^-MantissaBits),
+// converted to the corresponding Go type: e.g. float32(1.1920929e-07) for Float32.
+// It is typically used as a tolerance, or to avoid divisions by zero in normalizations.
+//
+// For non-float dtypes (including complex numbers) it returns zero, int8(0) for sub-byte integers, or nil
+// for dtypes without a Go type (e.g. F8E4M3).
+func (dtype DType) Epsilon() any {
+	if !dtype.IsFloat() {
+		return dtype.zeroValue()
+	}
+	return dtype.floatValue(math.Ldexp(1, -dtype.MantissaBits()))
+}
+
+// MantissaBits returns the number of explicitly stored mantissa (fraction) bits of float dtypes, not counting
+// the implicit leading bit: e.g. 23 for Float32 or 7 for BFloat16. For complex dtypes, it returns the value of
+// their real component dtype.
+//
+// It returns 0 for non-float dtypes.
+func (dtype DType) MantissaBits() int {
+	switch dtype {
+	case Float64, Complex128:
+		return 52
+	case Float32, Complex64:
+		return 23
+	case Float16:
+		return 10
+	case BFloat16:
+		return 7
+	case F8E4M3FN:
+		return 3
+	case F8E5M2:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// exponentBits returns the number of exponent bits of float dtypes, or of the real component of complex dtypes.
+// It returns 0 for other dtypes.
+func (dtype DType) exponentBits() int {
+	switch dtype {
+	case Float64, Complex128:
+		return 11
+	case Float32, Complex64, BFloat16:
+		return 8
+	case Float16, F8E5M2:
+		return 5
+	case F8E4M3FN:
+		return 4
+	default:
+		return 0
+	}
+}
+
+// SmallestNonZero returns the smallest positive value for dtype, converted to the corresponding Go type.
+// For float dtypes it is the smallest denormal value, and for integers it is 1.
+// There is no smallest non-zero value for complex numbers, since they are not ordered, and zero is returned.
+// Sub-byte integers return int8(1) and dtypes without a Go type (e.g. F8E4M3) return nil.
+func (dtype DType) SmallestNonZero() any {
 	switch dtype {
 	case Int64:
 		return int64(1)
@@ -357,6 +602,9 @@ func (dtype DType) SmallestNonZeroValueForDType() any {
 	case Uint8:
 		return uint8(1)
 
+	case S4, U4, S2, U2:
+		return int8(1)
+
 	case Bool:
 		return true
 
@@ -368,17 +616,27 @@ func (dtype DType) SmallestNonZeroValueForDType() any {
 		return float16.Float16(0x0001) // 1p-24, see discussion in https://github.com/x448/float16/pull/46
 	case BFloat16:
 		return bfloat16.SmallestNonzero // 1p-24, see discussion in https://github.com/x448/float16/pull/46
+	case F8E4M3FN:
+		return float8.E4M3FNFromBits(0x01) // 1p-9
+	case F8E5M2:
+		return float8.E5M2FromBits(0x01) // 1p-16
 
 	default:
 		// For invalid dtypes (like complex numbers), return zero.
-		return reflect.New(dtype.GoType()).Elem().Interface()
+		return dtype.zeroValue()
 	}
 }
 
-// IsFloat returns whether dtype is a supported float -- float types not yet supported will return false.
+// IsFloat returns whether dtype is a supported float, including the 8-bit floats (see IsFloat8).
+// Float types not yet supported will return false.
 // It returns false for complex numbers.
 func (dtype DType) IsFloat() bool {
-	return dtype == Float32 || dtype == Float64 || dtype == Float16 || dtype == BFloat16
+	return dtype == Float32 || dtype == Float64 || dtype == Float16 || dtype == BFloat16 || dtype.IsFloat8()
+}
+
+// IsFloat8 returns whether dtype is a supported float with 8 bits: [F8E4M3FN] or [F8E5M2].
+func (dtype DType) IsFloat8() bool {
+	return dtype == F8E4M3FN || dtype == F8E5M2
 }
 
 // IsFloat16 returns whether dtype is a supported float with 16 bits: [Float16] or [BFloat16].
@@ -416,14 +674,27 @@ func (dtype DType) IsInt() bool {
 		dtype == Uint8 || dtype == Uint16 || dtype == Uint32 || dtype == Uint64
 }
 
-// IsUnsigned returns whether dtype is one of the unsigned (only int for now) types.
+// IsInteger returns whether dtype is an integer type, including the sub-byte integers (S4, U4, S2, U2)
+// that IsInt excludes, since they have no corresponding Go type.
+func (dtype DType) IsInteger() bool {
+	return dtype.IsInt() || dtype.isSubByteInteger()
+}
+
+// IsUnsigned returns whether dtype is one of the unsigned integer types, including the sub-byte U4 and U2.
 func (dtype DType) IsUnsigned() bool {
-	return dtype == Uint8 || dtype == Uint16 || dtype == Uint32 || dtype == Uint64
+	return dtype == Uint8 || dtype == Uint16 || dtype == Uint32 || dtype == Uint64 || dtype == U4 || dtype == U2
+}
+
+// Bytes returns the number of bytes needed to store one element by itself: Bits rounded up to whole bytes.
+// For sub-byte dtypes (e.g. S4 or F4E2M1FN) it is 1, while Size returns 0: arrays of these dtypes are bit-packed,
+// use SizeForAxes for their size.
+func (dtype DType) Bytes() int {
+	return (dtype.Bits() + 7) / 8
 }
 
 // IsSupported returns whether dtype is supported by `gopjrt`.
 func (dtype DType) IsSupported() bool {
-	return dtype == Bool || dtype == Float16 || dtype == BFloat16 || dtype == Float32 || dtype == Float64 || dtype == Int64 || dtype == Int32 || dtype == Int16 || dtype == Int8 || dtype == Uint32 || dtype == Uint16 || dtype == Uint8 || dtype == Complex64 || dtype == Complex128
+	return dtype == Bool || dtype == Float16 || dtype == BFloat16 || dtype == F8E4M3FN || dtype == F8E5M2 || dtype == Float32 || dtype == Float64 || dtype == Int64 || dtype == Int32 || dtype == Int16 || dtype == Int8 || dtype == Uint64 || dtype == Uint32 || dtype == Uint16 || dtype == Uint8 || dtype == Complex64 || dtype == Complex128
 }
 
 // IsPromotableTo returns whether dtype can be promoted to target.
@@ -448,8 +719,14 @@ func (dtype DType) IsPromotableTo(target DType) bool {
 		return false
 	}
 
-	// For integer and float types, check bitwidth
-	if dtype.IsInt() || dtype.IsFloat() || dtype.IsComplex() {
+	// Floats (and complex numbers) must fit both the exponent range and the precision of the target:
+	// e.g. F8E4M3FN can't be promoted to F8E5M2, which has fewer mantissa bits.
+	if dtype.IsFloat() || dtype.IsComplex() {
+		return dtype.exponentBits() <= target.exponentBits() && dtype.MantissaBits() <= target.MantissaBits()
+	}
+
+	// For integer types, check bitwidth
+	if dtype.IsInt() {
 		return dtype.Bits() <= target.Bits()
 	}
 	return false
@@ -462,7 +739,7 @@ func (dtype DType) IsPromotableTo(target DType) bool {
 // Notice Go's `int` type is not portable, since it may translate to dtypes Int32 or Int64 depending
 // on the platform.
 type Supported interface {
-	bool | float16.Float16 | bfloat16.BFloat16 |
+	bool | float16.Float16 | bfloat16.BFloat16 | float8.E4M3FN | float8.E5M2 |
 		float32 | float64 | int | int8 | int16 | int32 | int64 | uint8 | uint16 | uint32 | uint64 |
 		complex64 | complex128
 }
@@ -2,9 +2,12 @@ package dtype
 
 import (
 	"math"
+	"math/rand/v2"
+	"reflect"
 	"testing"
 
 	"github.com/sebffischer/backend/backend/dtype/bfloat16"
+	"github.com/sebffischer/backend/backend/dtype/float8"
 	"github.com/stretchr/testify/require"
 	"github.com/x448/float16"
 )
@@ -40,12 +43,17 @@ func TestFromAny(t *testing.T) {
 	require.Equal(t, Float32, FromAny(float32(13)))
 	require.Equal(t, BFloat16, FromAny(bfloat16.FromFloat32(1.0)))
 	require.Equal(t, Float16, FromAny(float16.Fromfloat32(3.0)))
+	require.Equal(t, F8E4M3FN, FromAny(float8.E4M3FNFromFloat32(3.0)))
+	require.Equal(t, F8E5M2, FromAny(float8.E5M2FromFloat32(3.0)))
+	require.Equal(t, F8E5M2, FromGenericsType[float8.E5M2]())
 }
 
 func TestSize(t *testing.T) {
 	require.Equal(t, 8, Int64.Size())
 	require.Equal(t, 4, Float32.Size())
 	require.Equal(t, 2, BFloat16.Size())
+	require.Equal(t, 1, F8E4M3FN.Size())
+	require.Equal(t, 1, F8E5M2.Size())
 }
 
 func TestSizeForDimensions(t *testing.T) {
@@ -58,4 +66,187 @@ func TestIsPromotableTo(t *testing.T) {
 	require.True(t, Float32.IsPromotableTo(Float64))
 	require.False(t, Float64.IsPromotableTo(Float32))
 	require.False(t, Int8.IsPromotableTo(Float32))
+
+	// Floats must fit both the exponent range and the precision of the target.
+	require.False(t, F8E4M3FN.IsPromotableTo(F8E5M2))
+	require.False(t, F8E5M2.IsPromotableTo(F8E4M3FN))
+	require.True(t, F8E5M2.IsPromotableTo(Float16))
+	require.False(t, Float16.IsPromotableTo(BFloat16))
+	require.False(t, BFloat16.IsPromotableTo(Float16))
+	require.True(t, BFloat16.IsPromotableTo(Float32))
+	require.True(t, Complex64.IsPromotableTo(Complex128))
+	require.False(t, Complex128.IsPromotableTo(Complex64))
+}
+
+func TestSubByte(t *testing.T) {
+	require.Equal(t, 4, S4.Bits())
+	require.Equal(t, 2, U2.Bits())
+	require.Equal(t, 0, U4.Size())
+	require.True(t, S2.IsSubByte())
+	require.False(t, Int8.IsSubByte())
+	require.Equal(t, 3, S4.SizeForAxes(5))
+	require.Equal(t, 2, U2.SizeForAxes(2, 4))
+	require.Equal(t, 1, U2.SizeForAxes())
+
+	packed, err := PackSubByte(S4, []int8{1, -1, -8, 7, 3})
+	require.NoError(t, err)
+	require.Equal(t, []byte{0xF1, 0x78, 0x03}, packed)
+	unpacked, err := UnpackSubByte(S4, packed, 5)
+	require.NoError(t, err)
+	require.Equal(t, []int8{1, -1, -8, 7, 3}, unpacked)
+
+	packed, err = PackSubByte(U2, []int8{0, 1, 2, 3, 3})
+	require.NoError(t, err)
+	require.Equal(t, []byte{0xE4, 0x03}, packed)
+	unpacked, err = UnpackSubByte(U2, packed, 5)
+	require.NoError(t, err)
+	require.Equal(t, []int8{0, 1, 2, 3, 3}, unpacked)
+
+	_, err = PackSubByte(U4, []int8{16})
+	require.Error(t, err)
+	_, err = PackSubByte(F4E2M1FN, []int8{1})
+	require.Error(t, err)
+	_, err = PackSubByte(Int8, []int8{1})
+	require.Error(t, err)
+	_, err = UnpackSubByte(S2, []byte{0}, 5)
+	require.Error(t, err)
+}
+
+func TestStochasticRounding(t *testing.T) {
+	// 1 + 2^-12 is between 1 and 1 + 2^-10 in float16, and between 1 and 1 + 2^-7 in bfloat16.
+	x := float32(1 + 1.0/4096)
+	require.Equal(t, float32(1), Float16FromFloat32Stochastic(x, 0).Float32())
+	require.Equal(t, float32(1+1.0/1024), Float16FromFloat32Stochastic(x, math.MaxUint32).Float32())
+	require.Equal(t, float32(-1), Float16FromFloat32Stochastic(-x, 0).Float32())
+	require.Equal(t, float32(1), bfloat16.FromFloat32Stochastic(x, 0).Float32())
+	require.Equal(t, float32(1+1.0/128), bfloat16.FromFloat32Stochastic(x, math.MaxUint32).Float32())
+
+	// Exact values are never changed.
+	require.Equal(t, float32(0.5), Float16FromFloat32Stochastic(0.5, math.MaxUint32).Float32())
+	require.Equal(t, float32(0.5), bfloat16.FromFloat32Stochastic(0.5, math.MaxUint32).Float32())
+
+	// Unbiased on average.
+	rng := rand.New(rand.NewPCG(1, 2))
+	const n = 20000
+	var sum16, sumB16 float64
+	for range n {
+		sum16 += float64(Float16FromFloat32Stochastic(x, rng.Uint32()).Float32())
+		sumB16 += float64(bfloat16.FromFloat32Stochastic(x, rng.Uint32()).Float32())
+	}
+	require.InDelta(t, float64(x), sum16/n, 1e-4)
+	require.InDelta(t, float64(x), sumB16/n, 1e-3)
+
+	// Special values.
+	require.True(t, Float16FromFloat32Stochastic(float32(math.NaN()), 7).IsNaN())
+	require.True(t, math.IsNaN(float64(bfloat16.FromFloat32Stochastic(float32(math.NaN()), math.MaxUint32).Float32())))
+	require.Equal(t, float16.Inf(1), Float16FromFloat32Stochastic(1e6, 7))
+	require.Equal(t, bfloat16.Inf(-1), bfloat16.FromFloat32Stochastic(float32(math.Inf(-1)), math.MaxUint32))
+	require.Equal(t, float32(65504), Float16FromFloat32Stochastic(65510, math.MaxUint32).Float32())
+	maxFinite := math.Float32frombits(0x7F7FFFFF)
+	require.Equal(t, bfloat16.FromBits(0x7F7F), bfloat16.FromFloat32Stochastic(maxFinite, math.MaxUint32))
+	require.Equal(t, bfloat16.FromBits(0xFF7F), bfloat16.FromFloat32Stochastic(-maxFinite, math.MaxUint32))
+}
+
+func TestDType_FiniteRangeAndEpsilon(t *testing.T) {
+	require.Equal(t, float32(math.MaxFloat32), Float32.Highest())
+	require.Equal(t, -math.MaxFloat64, Float64.Lowest())
+	require.Equal(t, float32(65504), Float16.Highest().(float16.Float16).Float32())
+	require.Equal(t, float32(-65504), Float16.Lowest().(float16.Float16).Float32())
+	require.False(t, math.IsInf(float64(BFloat16.Highest().(bfloat16.BFloat16).Float32()), 0))
+	require.Equal(t, float32(448), F8E4M3FN.Highest().(float8.E4M3FN).Float32())
+	require.Equal(t, float32(-57344), F8E5M2.Lowest().(float8.E5M2).Float32())
+	require.Equal(t, int8(math.MinInt8), Int8.Lowest())
+	require.Equal(t, int8(math.MinInt8), Int8.LowestValue())
+	require.Equal(t, uint16(math.MaxUint16), Uint16.Highest())
+	require.Equal(t, complex64(0), Complex64.Highest())
+
+	require.Equal(t, float32(math.SmallestNonzeroFloat32), Float32.SmallestNonZero())
+	require.Equal(t, int32(1), Int32.SmallestNonZero())
+
+	require.Equal(t, float32(1.1920929e-07), Float32.Epsilon())
+	require.Equal(t, 2.220446049250313e-16, Float64.Epsilon())
+	require.Equal(t, float32(1.0/1024), Float16.Epsilon().(float16.Float16).Float32())
+	require.Equal(t, float32(1.0/128), BFloat16.Epsilon().(bfloat16.BFloat16).Float32())
+	require.Equal(t, float32(0.125), F8E4M3FN.Epsilon().(float8.E4M3FN).Float32())
+	require.Equal(t, float32(0.25), F8E5M2.Epsilon().(float8.E5M2).Float32())
+	require.Equal(t, int64(0), Int64.Epsilon())
+
+	require.Equal(t, 23, Float32.MantissaBits())
+	require.Equal(t, 52, Complex128.MantissaBits())
+	require.Equal(t, 7, BFloat16.MantissaBits())
+	require.Equal(t, 0, Int32.MantissaBits())
+
+	// Sub-byte integers use int8, the unpacked type of PackSubByte.
+	for _, tc := range []struct {
+		dtype           DType
+		lowest, highest int8
+	}{{S4, -8, 7}, {U4, 0, 15}, {S2, -2, 1}, {U2, 0, 3}} {
+		require.Equal(t, tc.lowest, tc.dtype.Lowest(), "%s", tc.dtype)
+		require.Equal(t, tc.highest, tc.dtype.Highest(), "%s", tc.dtype)
+		require.Equal(t, int8(1), tc.dtype.SmallestNonZero(), "%s", tc.dtype)
+		require.Equal(t, int8(0), tc.dtype.Epsilon(), "%s", tc.dtype)
+	}
+
+	// All declared dtypes have a bit width, and Bytes rounds it up.
+	for _, dt := range DTypeValues() {
+		var bits, bytes int
+		require.NotPanics(t, func() { bits, bytes = dt.Bits(), dt.Bytes() }, "%s", dt)
+		require.Equal(t, (bits+7)/8, bytes, "%s", dt)
+		require.Equal(t, dt != InvalidDType, bits > 0, "%s", dt)
+		if dt.IsSupported() {
+			require.Equal(t, int(dt.GoType().Size()), dt.Size(), "%s", dt)
+		}
+	}
+	require.Equal(t, 4, F4E2M1FN.Bits())
+	require.True(t, F4E2M1FN.IsSubByte())
+	require.True(t, F6E3M2FN.IsSubByte())
+	require.False(t, F4E2M1FN.IsInteger())
+	require.Equal(t, 1, F8E4M3.Bytes())
+
+	// All declared dtypes: values have the dtype's Go type, int8 for sub-byte integers, or nil if there is none.
+	for _, dt := range DTypeValues() {
+		for name, fn := range map[string]func() any{
+			"Lowest": dt.Lowest, "Highest": dt.Highest, "LowestValue": dt.LowestValue, "HighestValue": dt.HighestValue,
+			"Epsilon": dt.Epsilon, "SmallestNonZero": dt.SmallestNonZero,
+		} {
+			var value any
+			require.NotPanics(t, func() { value = fn() }, "%s.%s()", dt, name)
+			switch {
+			case dt.IsSupported():
+				require.Equal(t, dt.GoType(), reflect.TypeOf(value), "%s.%s()", dt, name)
+			case dt == S4 || dt == U4 || dt == S2 || dt == U2:
+				require.IsType(t, int8(0), value, "%s.%s()", dt, name)
+			default:
+				require.Nil(t, value, "%s.%s()", dt, name)
+			}
+		}
+	}
+}
+
+func TestCategoryPredicates(t *testing.T) {
+	for _, dt := range []DType{Float32, Float64, Float16, BFloat16, F8E4M3FN, F8E5M2} {
+		require.True(t, dt.IsFloat(), dt.String())
+		require.False(t, dt.IsInteger(), dt.String())
+	}
+	require.True(t, F8E5M2.IsFloat8())
+	require.False(t, Float16.IsFloat8())
+	require.False(t, Complex64.IsFloat())
+	require.True(t, Complex128.IsComplex())
+
+	require.True(t, Int8.IsInteger())
+	require.True(t, S4.IsInteger())
+	require.False(t, S4.IsInt())
+	require.True(t, U2.IsUnsigned())
+	require.False(t, S2.IsUnsigned())
+	require.False(t, Bool.IsInteger())
+
+	require.True(t, F8E4M3FN.IsPromotableTo(Float16))
+	require.False(t, Float16.IsPromotableTo(F8E5M2))
+
+	require.Equal(t, 8, F8E4M3FN.Bits())
+	require.Equal(t, 1, F8E4M3FN.Bytes())
+	require.Equal(t, 8, Float64.Bytes())
+	require.Equal(t, 16, Complex128.Bytes())
+	require.Equal(t, 1, S4.Bytes())
+	require.Equal(t, 1, U2.Bytes())
 }
@@ -0,0 +1,278 @@
+// Package float8 is a software implementation of the 8-bit floating point formats
+// F8E4M3FN and F8E5M2 described in [FP8 Formats for Deep Learning](https://arxiv.org/pdf/2209.05433).
+//
+// Like the bfloat16 package, values are simply stored as their bits, and arithmetic is done by converting
+// to float32, operating, and rounding the result back (round-to-nearest-even).
+package float8
+
+import (
+	"math"
+	"strconv"
+)
+
+// E4M3FN is an 8-bit float with 1 sign bit, 4 exponent bits (bias 7) and 3 mantissa bits.
+// It has no infinities ("FN" stands for "finite"), and only one NaN mantissa (S.1111.111).
+// Its largest finite value is 448.
+type E4M3FN uint8
+
+// E5M2 is an 8-bit float with 1 sign bit, 5 exponent bits (bias 15) and 2 mantissa bits,
+// following IEEE-754 conventions for infinities and NaNs. It's the upper byte of a float16.
+// Its largest finite value is 57344.
+type E5M2 uint8
+
+// format describes the layout of an 8-bit float.
+type format struct {
+	mantissaBits int
+	bias         int
+
+	// maxFinite is the bits of the largest finite magnitude.
+	maxFinite uint8
+
+	// overflow is the bits of the magnitude used for values that overflow: infinity or NaN.
+	overflow uint8
+
+	// nan is the bits of the canonical NaN magnitude.
+	nan uint8
+
+	hasInf bool
+}
+
+var (
+	e4m3fnFormat = format{mantissaBits: 3, bias: 7, maxFinite: 0x7E, overflow: 0x7F, nan: 0x7F}
+	e5m2Format   = format{mantissaBits: 2, bias: 15, maxFinite: 0x7B, overflow: 0x7C, nan: 0x7E, hasInf: true}
+)
+
+// encode converts x to the bits of the given format, rounding to nearest (ties to even).
+func (f format) encode(x float64) uint8 {
+	var sign uint8
+	if math.Signbit(x) {
+		sign = 0x80
+		x = -x
+	}
+	if math.IsNaN(x) {
+		return sign | f.nan
+	}
+	if math.IsInf(x, 0) {
+		return sign | f.overflow
+	}
+	if x == 0 {
+		return sign
+	}
+
+	_, exp := math.Frexp(x) // x = frac * 2^exp, with frac in [0.5, 1).
+	exp--                   // Now x = (2*frac) * 2^exp, with 2*frac in [1, 2).
+	minNormalExp := 1 - f.bias
+	var magnitude float64
+	if exp < minNormalExp {
+		// Subnormal: the rounded multiple of the smallest subnormal is the encoding itself. If it rounds up to
+		// 1<<mantissaBits it naturally becomes the smallest normal.
+		magnitude = math.RoundToEven(math.Ldexp(x, f.mantissaBits-minNormalExp))
+	} else {
+		q := math.RoundToEven(math.Ldexp(x, f.mantissaBits-exp)) // In [1<<mantissaBits, 2<<mantissaBits].
+		if q == float64(int(2)<<f.mantissaBits) {
+			exp++
+			q /= 2
+		}
+		magnitude = float64((exp+f.bias)<<f.mantissaBits) + q - float64(int(1)<<f.mantissaBits)
+	}
+	if magnitude > float64(f.maxFinite) {
+		return sign | f.overflow
+	}
+	return sign | uint8(magnitude)
+}
+
+// encodeStochastic converts x to the bits of the given format with stochastic rounding: x is rounded up
+// (in magnitude) with probability proportional to its distance to the lower representable value, using
+// random as a uniformly distributed source of randomness.
+//
+// Values beyond the largest finite value that round to nearest to it are not rounded up to the overflow value.
+func (f format) encodeStochastic(x float64, random uint32) uint8 {
+	nearest := f.encode(x)
+	sign, magnitude := nearest&0x80, nearest&0x7F
+	if magnitude > f.maxFinite {
+		return nearest // NaN or overflow.
+	}
+	abs := math.Abs(x)
+	low, high := magnitude, magnitude
+	switch value := f.decode(magnitude); {
+	case value > abs:
+		low--
+	case value < abs:
+		high++
+	default:
+		return nearest // Exact.
+	}
+	if high > f.maxFinite {
+		return sign | low
+	}
+	lowValue, highValue := f.decode(low), f.decode(high)
+	if float64(random) >= (highValue-abs)/(highValue-lowValue)*(1<<32) {
+		return sign | high
+	}
+	return sign | low
+}
+
+// decode converts the bits of the given format to a float64. Conversion is exact.
+func (f format) decode(bits uint8) float64 {
+	sign := 1.0
+	if bits&0x80 != 0 {
+		sign = -1.0
+	}
+	magnitude := bits & 0x7F
+	if magnitude > f.maxFinite {
+		if f.hasInf && magnitude == f.overflow {
+			return math.Inf(int(sign))
+		}
+		return math.NaN()
+	}
+	expField := int(magnitude >> f.mantissaBits)
+	mantissa := float64(magnitude & (1<<f.mantissaBits - 1))
+	if expField == 0 {
+		return sign * math.Ldexp(mantissa, 1-f.bias-f.mantissaBits)
+	}
+	return sign * math.Ldexp(mantissa+float64(int(1)<<f.mantissaBits), expField-f.bias-f.mantissaBits)
+}
+
+// E4M3FNFromFloat32 converts a float32 to an E4M3FN, rounding to nearest (ties to even).
+// Values that overflow the largest finite value (448), as well as infinities, become NaN.
+func E4M3FNFromFloat32(x float32) E4M3FN {
+	return E4M3FN(e4m3fnFormat.encode(float64(x)))
+}
+
+// E4M3FNFromFloat64 converts a float64 to an E4M3FN. See E4M3FNFromFloat32.
+func E4M3FNFromFloat64(x float64) E4M3FN {
+	return E4M3FN(e4m3fnFormat.encode(x))
+}
+
+// E4M3FNFromFloat32Stochastic converts a float32 to an E4M3FN using stochastic rounding: x is rounded to one of
+// the two nearest representable values, with probabilities proportional to their proximity, so the rounding
+// is unbiased on average. random must be uniformly distributed (e.g. from math/rand/v2.Uint32); a value of 0
+// always rounds towards zero.
+//
+// NaN and infinities are converted as in E4M3FNFromFloat32.
+func E4M3FNFromFloat32Stochastic(x float32, random uint32) E4M3FN {
+	return E4M3FN(e4m3fnFormat.encodeStochastic(float64(x), random))
+}
+
+// E4M3FNFromBits converts an uint8 to an E4M3FN.
+func E4M3FNFromBits(bits uint8) E4M3FN {
+	return E4M3FN(bits)
+}
+
+// Float32 converts the E4M3FN to a float32. The conversion is exact.
+func (f E4M3FN) Float32() float32 {
+	return float32(e4m3fnFormat.decode(uint8(f)))
+}
+
+// Float64 converts the E4M3FN to a float64. The conversion is exact.
+func (f E4M3FN) Float64() float64 {
+	return e4m3fnFormat.decode(uint8(f))
+}
+
+// Bits convert E4M3FN to an uint8.
+func (f E4M3FN) Bits() uint8 {
+	return uint8(f)
+}
+
+// IsNaN reports whether f is a NaN.
+func (f E4M3FN) IsNaN() bool {
+	return uint8(f)&0x7F == e4m3fnFormat.nan
+}
+
+// String implements fmt.Stringer, and prints a float representation of the E4M3FN.
+func (f E4M3FN) String() string {
+	return strconv.FormatFloat(f.Float64(), 'g', -1, 32)
+}
+
+// Add returns f + g, rounded to E4M3FN.
+func (f E4M3FN) Add(g E4M3FN) E4M3FN { return E4M3FNFromFloat32(f.Float32() + g.Float32()) }
+
+// Sub returns f - g, rounded to E4M3FN.
+func (f E4M3FN) Sub(g E4M3FN) E4M3FN { return E4M3FNFromFloat32(f.Float32() - g.Float32()) }
+
+// Mul returns f * g, rounded to E4M3FN.
+func (f E4M3FN) Mul(g E4M3FN) E4M3FN { return E4M3FNFromFloat32(f.Float32() * g.Float32()) }
+
+// Div returns f / g, rounded to E4M3FN.
+func (f E4M3FN) Div(g E4M3FN) E4M3FN { return E4M3FNFromFloat32(f.Float32() / g.Float32()) }
+
+// Neg returns -f.
+func (f E4M3FN) Neg() E4M3FN { return f ^ 0x80 }
+
+// E5M2FromFloat32 converts a float32 to an E5M2, rounding to nearest (ties to even).
+// Values that overflow the largest finite value (57344) become infinities.
+func E5M2FromFloat32(x float32) E5M2 {
+	return E5M2(e5m2Format.encode(float64(x)))
+}
+
+// E5M2FromFloat64 converts a float64 to an E5M2. See E5M2FromFloat32.
+func E5M2FromFloat64(x float64) E5M2 {
+	return E5M2(e5m2Format.encode(x))
+}
+
+// E5M2FromFloat32Stochastic converts a float32 to an E5M2 using stochastic rounding.
+// See E4M3FNFromFloat32Stochastic.
+func E5M2FromFloat32Stochastic(x float32, random uint32) E5M2 {
+	return E5M2(e5m2Format.encodeStochastic(float64(x), random))
+}
+
+// E5M2FromBits converts an uint8 to an E5M2.
+func E5M2FromBits(bits uint8) E5M2 {
+	return E5M2(bits)
+}
+
+// Float32 converts the E5M2 to a float32. The conversion is exact.
+func (f E5M2) Float32() float32 {
+	return float32(e5m2Format.decode(uint8(f)))
+}
+
+// Float64 converts the E5M2 to a float64. The conversion is exact.
+func (f E5M2) Float64() float64 {
+	return e5m2Format.decode(uint8(f))
+}
+
+// Bits convert E5M2 to an uint8.
+func (f E5M2) Bits() uint8 {
+	return uint8(f)
+}
+
+// IsNaN reports whether f is a NaN.
+func (f E5M2) IsNaN() bool {
+	return uint8(f)&0x7F > e5m2Format.overflow
+}
+
+// String implements fmt.Stringer, and prints a float representation of the E5M2.
+func (f E5M2) String() string {
+	return strconv.FormatFloat(f.Float64(), 'g', -1, 32)
+}
+
+// Add returns f + g, rounded to E5M2.
+func (f E5M2) Add(g E5M2) E5M2 { return E5M2FromFloat32(f.Float32() + g.Float32()) }
+
+// Sub returns f - g, rounded to E5M2.
+func (f E5M2) Sub(g E5M2) E5M2 { return E5M2FromFloat32(f.Float32() - g.Float32()) }
+
+// Mul returns f * g, rounded to E5M2.
+func (f E5M2) Mul(g E5M2) E5M2 { return E5M2FromFloat32(f.Float32() * g.Float32()) }
+
+// Div returns f / g, rounded to E5M2.
+func (f E5M2) Div(g E5M2) E5M2 { return E5M2FromFloat32(f.Float32() / g.Float32()) }
+
+// Neg returns -f.
+func (f E5M2) Neg() E5M2 { return f ^ 0x80 }
+
+// E5M2Inf returns an E5M2 with an infinity value with the specified sign.
+// A sign >= 0 returns positive infinity.
+// A sign < 0 returns negative infinity.
+func E5M2Inf(sign int) E5M2 {
+	if sign < 0 {
+		return E5M2(0x80 | e5m2Format.overflow)
+	}
+	return E5M2(e5m2Format.overflow)
+}
+
+// E4M3FNNaN returns the canonical E4M3FN NaN.
+func E4M3FNNaN() E4M3FN { return E4M3FN(e4m3fnFormat.nan) }
+
+// E5M2NaN returns the canonical E5M2 NaN.
+func E5M2NaN() E5M2 { return E5M2(e5m2Format.nan) }
@@ -0,0 +1,105 @@
+package float8
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/x448/float16"
+)
+
+func TestE4M3FN(t *testing.T) {
+	require.Equal(t, E4M3FN(0x38), E4M3FNFromFloat32(1))
+	require.Equal(t, E4M3FN(0x7E), E4M3FNFromFloat32(448))
+	require.Equal(t, E4M3FN(0x01), E4M3FNFromFloat64(math.Ldexp(1, -9)))
+	require.Equal(t, float32(448), E4M3FN(0x7E).Float32())
+	require.Equal(t, float32(-2), E4M3FN(0xC0).Float32())
+
+	// Overflow and infinities become NaN, there are no infinities.
+	require.True(t, E4M3FNFromFloat32(480).IsNaN())
+	require.True(t, E4M3FNFromFloat32(float32(math.Inf(-1))).IsNaN())
+	require.Equal(t, E4M3FN(0x7E), E4M3FNFromFloat32(460)) // Rounds down to 448.
+
+	// Ties to even: 1.0625 is half-way between 1 (mantissa 000) and 1.125 (mantissa 001).
+	require.Equal(t, float32(1), E4M3FNFromFloat32(1.0625).Float32())
+	require.Equal(t, float32(1.25), E4M3FNFromFloat32(1.1875).Float32())
+
+	// Round-trip over all values.
+	for bits := 0; bits < 256; bits++ {
+		f := E4M3FNFromBits(uint8(bits))
+		if f.IsNaN() {
+			require.True(t, math.IsNaN(f.Float64()))
+			continue
+		}
+		require.Equal(t, f, E4M3FNFromFloat32(f.Float32()), "bits=%#x", bits)
+	}
+
+	// Arithmetic.
+	one, two := E4M3FNFromFloat32(1), E4M3FNFromFloat32(2)
+	require.Equal(t, float32(3), one.Add(two).Float32())
+	require.Equal(t, float32(-1), one.Sub(two).Float32())
+	require.Equal(t, float32(2), one.Mul(two).Float32())
+	require.Equal(t, float32(0.5), one.Div(two).Float32())
+	require.Equal(t, float32(-1), one.Neg().Float32())
+	require.Equal(t, "448", E4M3FN(0x7E).String())
+}
+
+func TestE5M2(t *testing.T) {
+	require.Equal(t, E5M2(0x3C), E5M2FromFloat32(1))
+	require.Equal(t, E5M2(0x7B), E5M2FromFloat32(57344))
+	require.Equal(t, E5M2Inf(1), E5M2FromFloat32(65536))
+	require.Equal(t, E5M2Inf(-1), E5M2FromFloat32(float32(math.Inf(-1))))
+	require.True(t, E5M2FromFloat32(float32(math.NaN())).IsNaN())
+	require.True(t, math.IsInf(E5M2Inf(-1).Float64(), -1))
+
+	// E5M2 is the upper byte of a float16: every value must match.
+	for bits := 0; bits < 256; bits++ {
+		f := E5M2FromBits(uint8(bits))
+		f16 := float16.Frombits(uint16(bits) << 8)
+		if f.IsNaN() {
+			require.True(t, f16.IsNaN())
+			continue
+		}
+		require.Equal(t, f16.Float32(), f.Float32(), "bits=%#x", bits)
+		require.Equal(t, f, E5M2FromFloat32(f.Float32()), "bits=%#x", bits)
+	}
+
+	one, two := E5M2FromFloat32(1), E5M2FromFloat32(2)
+	require.Equal(t, float32(3), one.Add(two).Float32())
+	require.Equal(t, float32(0.5), one.Div(two).Float32())
+	require.Equal(t, E5M2Inf(1), E5M2FromFloat32(57344).Mul(two))
+}
+
+func TestStochasticRounding(t *testing.T) {
+	// Exact values are never changed.
+	for _, random := range []uint32{0, 1 << 31, math.MaxUint32} {
+		require.Equal(t, E4M3FNFromFloat32(1.5), E4M3FNFromFloat32Stochastic(1.5, random))
+		require.Equal(t, E5M2FromFloat32(-3), E5M2FromFloat32Stochastic(-3, random))
+	}
+
+	// 1.0625 is between 1 and 1.125 in E4M3FN, and between 1 and 1.25 in E5M2.
+	require.Equal(t, float32(1), E4M3FNFromFloat32Stochastic(1.0625, 0).Float32())
+	require.Equal(t, float32(1.125), E4M3FNFromFloat32Stochastic(1.0625, math.MaxUint32).Float32())
+	require.Equal(t, float32(-1.25), E5M2FromFloat32Stochastic(-1.0625, math.MaxUint32).Float32())
+
+	// Unbiased on average.
+	rng := rand.New(rand.NewPCG(1, 2))
+	const n = 20000
+	var sum float64
+	for range n {
+		sum += E4M3FNFromFloat32Stochastic(1.0625, rng.Uint32()).Float64()
+	}
+	require.InDelta(t, 1.0625, sum/n, 0.005)
+	sum = 0
+	for range n {
+		sum += E5M2FromFloat32Stochastic(-0.01, rng.Uint32()).Float64()
+	}
+	require.InDelta(t, -0.01, sum/n, 0.0005)
+
+	// Special values.
+	require.True(t, E4M3FNFromFloat32Stochastic(float32(math.NaN()), 7).IsNaN())
+	require.True(t, E4M3FNFromFloat32Stochastic(1000, 7).IsNaN())
+	require.Equal(t, float32(448), E4M3FNFromFloat32Stochastic(450, math.MaxUint32).Float32())
+	require.Equal(t, E5M2Inf(-1), E5M2FromFloat32Stochastic(float32(math.Inf(-1)), 7))
+}
@@ -0,0 +1,74 @@
+package dtype
+
+import (
+	"github.com/pkg/errors"
+)
+
+// IsSubByte returns whether the dtype uses less than one byte per element: the integers S4, U4, S2 and U2,
+// and the floats F4E2M1FN, F6E3M2FN and F6E2M3FN. Arrays of these dtypes are bit-packed, see PackSubByte.
+func (dtype DType) IsSubByte() bool {
+	bits := dtype.Bits()
+	return bits > 0 && bits < 8
+}
+
+// subByteRange returns the range of values representable by a sub-byte integer dtype.
+func (dtype DType) subByteRange() (lowest, highest int8) {
+	bits := dtype.subByteBits()
+	if dtype == S4 || dtype == S2 {
+		return -1 << (bits - 1), 1<<(bits-1) - 1
+	}
+	return 0, 1<<bits - 1
+}
+
+// PackSubByte packs values of a sub-byte dtype (S4, U4, S2, U2), one per int8, into the bit-packed row-major
+// layout: consecutive elements fill each byte starting from the least significant bits. The last byte is
+// padded with zeros.
+//
+// It returns an error if dtype is not a sub-byte integer dtype, or if any value is out of the range of the dtype.
+func PackSubByte(dtype DType, values []int8) ([]byte, error) {
+	if !dtype.isSubByteInteger() {
+		return nil, errors.Errorf("PackSubByte: dtype %s is not a sub-byte integer dtype", dtype)
+	}
+	bits := dtype.subByteBits()
+	lowest, highest := dtype.subByteRange()
+	mask := byte(1)<<bits - 1
+	elementsPerByte := 8 / bits
+	packed := make([]byte, dtype.SizeForAxes(len(values)))
+	for ii, value := range values {
+		if value < lowest || value > highest {
+			return nil, errors.Errorf("PackSubByte: value %d at position %d out of range [%d, %d] for dtype %s", value, ii, lowest, highest, dtype)
+		}
+		shift := (ii % elementsPerByte) * bits
+		packed[ii/elementsPerByte] |= (byte(value) & mask) << shift
+	}
+	return packed, nil
+}
+
+// UnpackSubByte is the inverse of PackSubByte: it unpacks numElements values of a sub-byte dtype from packed
+// into one int8 per element, sign-extending the values of signed dtypes (S4, S2).
+//
+// It returns an error if dtype is not a sub-byte integer dtype or if packed is too short for numElements.
+func UnpackSubByte(dtype DType, packed []byte, numElements int) ([]int8, error) {
+	if !dtype.isSubByteInteger() {
+		return nil, errors.Errorf("UnpackSubByte: dtype %s is not a sub-byte integer dtype", dtype)
+	}
+	if numElements < 0 || len(packed) < dtype.SizeForAxes(numElements) {
+		return nil, errors.Errorf("UnpackSubByte: %d bytes is not enough for %d elements of dtype %s", len(packed), numElements, dtype)
+	}
+	bits := dtype.subByteBits()
+	mask := byte(1)<<bits - 1
+	signed := dtype == S4 || dtype == S2
+	elementsPerByte := 8 / bits
+	values := make([]int8, numElements)
+	for ii := range values {
+		shift := (ii % elementsPerByte) * bits
+		value := (packed[ii/elementsPerByte] >> shift) & mask
+		if signed {
+			// Sign-extend: shift the value to the top of the byte and arithmetic-shift it back.
+			values[ii] = int8(value<<(8-bits)) >> (8 - bits)
+		} else {
+			values[ii] = int8(value)
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,41 @@
+package dtype
+
+import (
+	"math"
+
+	"github.com/x448/float16"
+)
+
+// Float16FromFloat32Stochastic converts a float32 to a Float16 using stochastic rounding: x is rounded to one of
+// the two nearest representable values, with probabilities proportional to their proximity, so the rounding
+// is unbiased on average. random must be uniformly distributed (e.g. from math/rand/v2.Uint32); a value of 0
+// always rounds towards zero.
+//
+// Values beyond the largest finite value that round to nearest to it are not rounded up to infinity.
+// See also bfloat16.FromFloat32Stochastic and float8.E4M3FNFromFloat32Stochastic.
+func Float16FromFloat32Stochastic(x float32, random uint32) float16.Float16 {
+	const maxFinite = 0x7BFF
+	nearest := float16.Fromfloat32(x).Bits()
+	sign, magnitude := nearest&0x8000, nearest&0x7FFF
+	if magnitude > maxFinite {
+		return float16.Frombits(nearest) // NaN or infinity.
+	}
+	abs := math.Abs(float64(x))
+	low, high := magnitude, magnitude
+	switch value := float64(float16.Frombits(magnitude).Float32()); {
+	case value > abs:
+		low--
+	case value < abs:
+		high++
+	default:
+		return float16.Frombits(nearest) // Exact.
+	}
+	if high > maxFinite {
+		return float16.Frombits(sign | low)
+	}
+	lowValue, highValue := float64(float16.Frombits(low).Float32()), float64(float16.Frombits(high).Float32())
+	if float64(random) >= (highValue-abs)/(highValue-lowValue)*(1<<32) {
+		return float16.Frombits(sign | high)
+	}
+	return float16.Frombits(sign | low)
+}
@@ -0,0 +1,89 @@
+// Package errs defines the kinds of errors returned by backends and their utilities, so callers can
+// programmatically distinguish user mistakes (e.g. an array type mismatch) from backend failures
+// (e.g. running out of device memory).
+//
+// Errors are tagged with a kind using New or Wrap, and checked with the standard errors.Is:
+//
+//	err := errs.New(errs.ErrArrayTypeMismatch, "Add(%s, %s): axes don't match", x, y)
+//	...
+//	if errors.Is(err, errs.ErrArrayTypeMismatch) { ... }
+//
+// Errors created by this package carry a stack trace (see github.com/pkg/errors), printed with "%+v".
+package errs
+
+import (
+	stderrors "errors"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// Kinds of errors. They are sentinel values meant to be used with errors.Is, not returned directly.
+var (
+	// ErrInvalidArgument is returned for invalid arguments that don't fit a more specific kind, e.g. an
+	// out-of-bounds axis.
+	ErrInvalidArgument = stderrors.New("invalid argument")
+
+	// ErrArrayTypeMismatch is returned when the array types (dtype or axes) of operands are incompatible,
+	// or don't match what is expected.
+	ErrArrayTypeMismatch = stderrors.New("array type mismatch")
+
+	// ErrUnsupportedOp is returned when a backend doesn't support an operation.
+	ErrUnsupportedOp = stderrors.New("unsupported op")
+
+	// ErrUnsupportedDType is returned when an operation or backend doesn't support a dtype.
+	ErrUnsupportedDType = stderrors.New("unsupported dtype")
+
+	// ErrOutOfMemory is returned when a backend fails to allocate memory.
+	ErrOutOfMemory = stderrors.New("out of memory")
+
+	// ErrBackendInternal is returned for failures internal to a backend, including recovered panics
+	// (see Recover). They are usually bugs, not caused by the user.
+	ErrBackendInternal = stderrors.New("backend internal error")
+)
+
+// kindError is an error tagged with a kind, and optionally wrapping a cause.
+type kindError struct {
+	kind  error
+	msg   string
+	cause error
+}
+
+// Error implements the error interface.
+func (e *kindError) Error() string {
+	if e.cause == nil {
+		return fmt.Sprintf("%s: %s", e.kind, e.msg)
+	}
+	return fmt.Sprintf("%s: %s: %s", e.kind, e.msg, e.cause)
+}
+
+// Unwrap returns the kind and the cause, so errors.Is and errors.As match both.
+func (e *kindError) Unwrap() []error {
+	if e.cause == nil {
+		return []error{e.kind}
+	}
+	return []error{e.kind, e.cause}
+}
+
+// New returns an error of the given kind (e.g. ErrUnsupportedOp), with the formatted message and a stack trace.
+func New(kind error, format string, args ...any) error {
+	return errors.WithStack(&kindError{kind: kind, msg: fmt.Sprintf(format, args...)})
+}
+
+// Wrap returns an error of the given kind wrapping err, with the formatted message and a stack trace.
+// Both errors.Is(result, kind) and errors.Is(result, err) are true.
+//
+// It returns nil if err is nil.
+func Wrap(kind, err error, format string, args ...any) error {
+	if err == nil {
+		return nil
+	}
+	return errors.WithStack(&kindError{kind: kind, msg: fmt.Sprintf(format, args...), cause: err})
+}
+
+// IsUserError returns whether err is of a kind caused by how the backend is used (invalid arguments,
+// array type mismatches, unsupported ops or dtypes), as opposed to a failure of the backend itself.
+func IsUserError(err error) bool {
+	return errors.Is(err, ErrInvalidArgument) || errors.Is(err, ErrArrayTypeMismatch) ||
+		errors.Is(err, ErrUnsupportedOp) || errors.Is(err, ErrUnsupportedDType)
+}
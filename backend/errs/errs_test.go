@@ -0,0 +1,44 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	err := New(ErrUnsupportedOp, "op %q not supported by %s", "FFT", "cpu")
+	require.EqualError(t, err, `unsupported op: op "FFT" not supported by cpu`)
+	require.ErrorIs(t, err, ErrUnsupportedOp)
+	require.NotErrorIs(t, err, ErrOutOfMemory)
+	require.True(t, IsUserError(err))
+	require.Contains(t, fmt.Sprintf("%+v", err), "errs_test.go")
+
+	// Kinds are preserved through further wrapping.
+	wrapped := pkgerrors.WithMessage(err, "Compile")
+	require.ErrorIs(t, wrapped, ErrUnsupportedOp)
+	wrapped = fmt.Errorf("serving: %w", err)
+	require.ErrorIs(t, wrapped, ErrUnsupportedOp)
+}
+
+func TestWrap(t *testing.T) {
+	err := Wrap(ErrOutOfMemory, io.ErrShortBuffer, "allocating %d bytes", 1024)
+	require.EqualError(t, err, "out of memory: allocating 1024 bytes: short buffer")
+	require.ErrorIs(t, err, ErrOutOfMemory)
+	require.ErrorIs(t, err, io.ErrShortBuffer)
+	require.False(t, IsUserError(err))
+	require.NoError(t, Wrap(ErrOutOfMemory, nil, "nothing"))
+
+	var target *customError
+	err = Wrap(ErrBackendInternal, &customError{code: 7}, "kernel failed")
+	require.True(t, errors.As(err, &target))
+	require.Equal(t, 7, target.code)
+}
+
+type customError struct{ code int }
+
+func (e *customError) Error() string { return fmt.Sprintf("code %d", e.code) }
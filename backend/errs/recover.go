@@ -0,0 +1,36 @@
+package errs
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Recover converts a panic into an ErrBackendInternal error stored in *err, so a bug in one kernel doesn't
+// crash the whole process. It must be called directly with defer, in a function with a named error result:
+//
+//	func (c *computation) Run(inputs []Buffer) (outputs []Buffer, err error) {
+//		defer errs.Recover(&err)
+//		...
+//	}
+//
+// If the panic value is an error, it is wrapped, so errors.Is and errors.As match it. The returned error carries
+// the stack trace of the panic, printed with "%+v". If there is no panic, *err is left unchanged.
+func Recover(err *error) {
+	if r := recover(); r != nil {
+		*err = panicError(r)
+	}
+}
+
+// Call calls fn, converting any panic into an ErrBackendInternal error, see Recover.
+func Call(fn func() error) (err error) {
+	defer Recover(&err)
+	return fn()
+}
+
+// panicError converts a recovered panic value to an error. It must be called from the deferred function, so the
+// stack trace still includes the frames that panicked.
+func panicError(r any) error {
+	if cause, ok := r.(error); ok {
+		return errors.WithStack(&kindError{kind: ErrBackendInternal, msg: "panic", cause: cause})
+	}
+	return New(ErrBackendInternal, "panic: %v", r)
+}
@@ -0,0 +1,42 @@
+package errs
+
+import (
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func panickingKernel(value any) error {
+	panic(value)
+}
+
+func TestRecover(t *testing.T) {
+	run := func(value any) (err error) {
+		defer Recover(&err)
+		return panickingKernel(value)
+	}
+	err := run("index out of range")
+	require.EqualError(t, err, "backend internal error: panic: index out of range")
+	require.ErrorIs(t, err, ErrBackendInternal)
+	require.Contains(t, fmt.Sprintf("%+v", err), "panickingKernel")
+
+	err = run(io.ErrUnexpectedEOF)
+	require.EqualError(t, err, "backend internal error: panic: unexpected EOF")
+	require.ErrorIs(t, err, ErrBackendInternal)
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	// Runtime errors are errors too.
+	err = Call(func() error {
+		var values []int
+		_ = values[3]
+		return nil
+	})
+	require.ErrorIs(t, err, ErrBackendInternal)
+	require.ErrorContains(t, err, "index out of range")
+
+	// No panic: the error is unchanged.
+	require.NoError(t, Call(func() error { return nil }))
+	require.ErrorIs(t, Call(func() error { return io.EOF }), io.EOF)
+}
@@ -0,0 +1,382 @@
+// Package gguf reads GGUF files, the weights format used by llama.cpp and the GGML ecosystem.
+//
+// It exposes the metadata key/values and the tensor descriptions of the file, and reads tensors into
+// host tensors (see package tensor), de-quantizing the common GGML quantization formats into float32.
+//
+// See the format specification in https://github.com/ggml-org/ggml/blob/master/docs/gguf.md
+//
+// Example:
+//
+//	f, err := gguf.Open("model.gguf")
+//	if err != nil { ... }
+//	defer f.Close()
+//	arch := f.Metadata["general.architecture"].(string)
+//	embeddings, err := f.ReadTensor("token_embd.weight")
+package gguf
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"math"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// magic is the first 4 bytes of every GGUF file.
+const magic = "GGUF"
+
+// DefaultAlignment of the tensor data, used if the metadata key "general.alignment" is not set.
+const DefaultAlignment = 32
+
+// maxAxes is the maximum number of axes of a tensor (GGML_MAX_DIMS).
+const maxAxes = 4
+
+// maxArrayDepth is the maximum nesting of metadata arrays, to bound the recursion on corrupt files.
+const maxArrayDepth = 8
+
+// maxPreallocatedValues caps the capacity preallocated for metadata arrays, which otherwise grow as values are read.
+const maxPreallocatedValues = 1024
+
+// File is a parsed GGUF file. Tensor data is only read on demand, with ReadTensor.
+type File struct {
+	// Version of the GGUF format: 2 and 3 are supported.
+	Version uint32
+
+	// Metadata holds the key/values of the file. Values are Go scalars (uint8, int8, uint16, int16, uint32,
+	// int32, uint64, int64, float32, float64, bool, string), typed slices of them for arrays
+	// (e.g. []string for the tokenizer vocabulary) or []any for arrays of arrays.
+	Metadata map[string]any
+
+	// Tensors in the order they are declared in the file.
+	Tensors []TensorInfo
+
+	r          io.ReaderAt
+	size       int64
+	dataOffset int64
+	closer     io.Closer
+}
+
+// TensorInfo describes a tensor stored in a GGUF file.
+type TensorInfo struct {
+	// Name of the tensor, e.g. "blk.0.attn_q.weight".
+	Name string
+
+	// AxisLengths of the tensor, in row-major order: notice GGML lists the dimensions in the reverse
+	// order (innermost first), they are reversed here.
+	AxisLengths []int
+
+	// Type is the GGML type of the stored values, possibly a quantized format.
+	Type GGMLType
+
+	// Offset of the tensor data, relative to the start of the data section.
+	Offset uint64
+}
+
+// NumElements returns the number of elements of the tensor. For the tensors of a File, Read checks it doesn't overflow.
+func (info TensorInfo) NumElements() int {
+	n := 1
+	for _, length := range info.AxisLengths {
+		n *= length
+	}
+	return n
+}
+
+// Open opens and parses the header of the GGUF file in path. The returned File must be closed after use.
+func Open(path string) (*File, error) {
+	osFile, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "gguf.Open(%q)", path)
+	}
+	stat, err := osFile.Stat()
+	if err != nil {
+		_ = osFile.Close()
+		return nil, errors.Wrapf(err, "gguf.Open(%q)", path)
+	}
+	f, err := Read(osFile, stat.Size())
+	if err != nil {
+		_ = osFile.Close()
+		return nil, errors.WithMessagef(err, "gguf.Open(%q)", path)
+	}
+	f.closer = osFile
+	return f, nil
+}
+
+// Close releases the underlying file, if the File was created with Open.
+func (f *File) Close() error {
+	if f.closer == nil {
+		return nil
+	}
+	err := f.closer.Close()
+	f.closer = nil
+	return err
+}
+
+// Read parses the header (metadata and tensor descriptions) of a GGUF file of the given size from r.
+// r must remain valid while tensors are read.
+func Read(r io.ReaderAt, size int64) (*File, error) {
+	hr := &headerReader{r: bufio.NewReader(io.NewSectionReader(r, 0, size)), size: size}
+	if m := hr.bytes(4); hr.err == nil && string(m) != magic {
+		return nil, errors.New("gguf: invalid file, magic string not found")
+	}
+	f := &File{r: r, size: size, Metadata: make(map[string]any)}
+	f.Version = hr.uint32()
+	if hr.err == nil && (f.Version < 2 || f.Version > 3) {
+		return nil, errors.Errorf("gguf: unsupported version %d", f.Version)
+	}
+	numTensors := hr.count(8)
+	numKVs := hr.count(8)
+	for ii := 0; ii < numKVs && hr.err == nil; ii++ {
+		key := hr.string()
+		valueType := valueType(hr.uint32())
+		f.Metadata[key] = hr.value(valueType)
+	}
+	for ii := 0; ii < numTensors && hr.err == nil; ii++ {
+		info := TensorInfo{Name: hr.string()}
+		numAxes := int(hr.uint32())
+		if numAxes > maxAxes {
+			hr.err = errors.Errorf("invalid number of axes %d for tensor %q", numAxes, info.Name)
+			break
+		}
+		info.AxisLengths = make([]int, numAxes)
+		numElements := 1
+		for axis := numAxes - 1; axis >= 0 && hr.err == nil; axis-- {
+			length := hr.uint64()
+			if length > math.MaxInt32 {
+				hr.err = errors.Errorf("invalid axis length %d for tensor %q", length, info.Name)
+				break
+			}
+			info.AxisLengths[axis] = int(length)
+			if length != 0 && numElements > math.MaxInt/int(length) {
+				hr.err = errors.Errorf("number of elements of tensor %q overflows", info.Name)
+				break
+			}
+			numElements *= int(length)
+		}
+		info.Type = GGMLType(hr.uint32())
+		info.Offset = hr.uint64()
+		f.Tensors = append(f.Tensors, info)
+	}
+	if hr.err != nil {
+		return nil, errors.WithMessage(hr.err, "gguf: failed to parse header")
+	}
+
+	alignment := int64(DefaultAlignment)
+	if value, found := f.Metadata["general.alignment"]; found {
+		a, ok := value.(uint32)
+		if !ok || a == 0 {
+			return nil, errors.Errorf("gguf: invalid general.alignment %v (%T)", value, value)
+		}
+		alignment = int64(a)
+	}
+	f.dataOffset = (hr.pos + alignment - 1) / alignment * alignment
+	for _, info := range f.Tensors {
+		if _, _, err := f.dataRange(info); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// Tensor returns the description of the tensor with the given name.
+func (f *File) Tensor(name string) (TensorInfo, bool) {
+	for _, info := range f.Tensors {
+		if info.Name == name {
+			return info, true
+		}
+	}
+	return TensorInfo{}, false
+}
+
+// valueType enumerates the types of metadata values.
+type valueType uint32
+
+const (
+	typeUint8   valueType = 0
+	typeInt8    valueType = 1
+	typeUint16  valueType = 2
+	typeInt16   valueType = 3
+	typeUint32  valueType = 4
+	typeInt32   valueType = 5
+	typeFloat32 valueType = 6
+	typeBool    valueType = 7
+	typeString  valueType = 8
+	typeArray   valueType = 9
+	typeUint64  valueType = 10
+	typeInt64   valueType = 11
+	typeFloat64 valueType = 12
+)
+
+// minSize returns the minimum number of bytes used to encode a value of the type: the scalar width,
+// the length of a string, or the element type and count of an array.
+func (t valueType) minSize() int64 {
+	switch t {
+	case typeUint8, typeInt8, typeBool:
+		return 1
+	case typeUint16, typeInt16:
+		return 2
+	case typeUint32, typeInt32, typeFloat32:
+		return 4
+	case typeUint64, typeInt64, typeFloat64, typeString:
+		return 8
+	case typeArray:
+		return 12
+	default:
+		return 1
+	}
+}
+
+// headerReader reads the little-endian encoded header, keeping track of the position and the first error.
+type headerReader struct {
+	r    *bufio.Reader
+	size int64
+	pos  int64
+	err  error
+
+	// arrayDepth is the nesting of the metadata array being read.
+	arrayDepth int
+}
+
+func (hr *headerReader) bytes(n int) []byte {
+	if hr.err != nil {
+		return nil
+	}
+	if int64(n) > hr.size-hr.pos {
+		hr.err = errors.Errorf("unexpected end of file reading %d bytes at position %d", n, hr.pos)
+		return nil
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(hr.r, buf); err != nil {
+		hr.err = errors.Wrapf(err, "failed reading %d bytes at position %d", n, hr.pos)
+		return nil
+	}
+	hr.pos += int64(n)
+	return buf
+}
+
+func (hr *headerReader) uint8() uint8 {
+	if b := hr.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (hr *headerReader) uint16() uint16 {
+	if b := hr.bytes(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (hr *headerReader) uint32() uint32 {
+	if b := hr.bytes(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (hr *headerReader) uint64() uint64 {
+	if b := hr.bytes(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
+
+// count reads an uint64 count of items that take at least minItemSize bytes each, and checks that
+// they fit in the rest of the file, to protect against allocating huge buffers for corrupt files.
+func (hr *headerReader) count(minItemSize int64) int {
+	n := hr.uint64()
+	if hr.err == nil && (n > math.MaxInt32 || int64(n)*minItemSize > hr.size-hr.pos) {
+		hr.err = errors.Errorf("invalid count %d at position %d", n, hr.pos-8)
+		return 0
+	}
+	return int(n)
+}
+
+func (hr *headerReader) string() string {
+	return string(hr.bytes(hr.count(1)))
+}
+
+// value reads a metadata value of the given type.
+func (hr *headerReader) value(t valueType) any {
+	switch t {
+	case typeUint8:
+		return hr.uint8()
+	case typeInt8:
+		return int8(hr.uint8())
+	case typeUint16:
+		return hr.uint16()
+	case typeInt16:
+		return int16(hr.uint16())
+	case typeUint32:
+		return hr.uint32()
+	case typeInt32:
+		return int32(hr.uint32())
+	case typeFloat32:
+		return math.Float32frombits(hr.uint32())
+	case typeBool:
+		return hr.uint8() != 0
+	case typeString:
+		return hr.string()
+	case typeUint64:
+		return hr.uint64()
+	case typeInt64:
+		return int64(hr.uint64())
+	case typeFloat64:
+		return math.Float64frombits(hr.uint64())
+	case typeArray:
+		if hr.arrayDepth >= maxArrayDepth {
+			if hr.err == nil {
+				hr.err = errors.Errorf("metadata arrays nested deeper than %d at position %d", maxArrayDepth, hr.pos)
+			}
+			return nil
+		}
+		hr.arrayDepth++
+		defer func() { hr.arrayDepth-- }()
+		elemType := valueType(hr.uint32())
+		n := hr.count(elemType.minSize())
+		switch elemType {
+		case typeUint8:
+			return readArray[uint8](hr, n, elemType)
+		case typeInt8:
+			return readArray[int8](hr, n, elemType)
+		case typeUint16:
+			return readArray[uint16](hr, n, elemType)
+		case typeInt16:
+			return readArray[int16](hr, n, elemType)
+		case typeUint32:
+			return readArray[uint32](hr, n, elemType)
+		case typeInt32:
+			return readArray[int32](hr, n, elemType)
+		case typeFloat32:
+			return readArray[float32](hr, n, elemType)
+		case typeBool:
+			return readArray[bool](hr, n, elemType)
+		case typeString:
+			return readArray[string](hr, n, elemType)
+		case typeUint64:
+			return readArray[uint64](hr, n, elemType)
+		case typeInt64:
+			return readArray[int64](hr, n, elemType)
+		case typeFloat64:
+			return readArray[float64](hr, n, elemType)
+		default:
+			return readArray[any](hr, n, elemType)
+		}
+	}
+	if hr.err == nil {
+		hr.err = errors.Errorf("unknown metadata value type %d at position %d", t, hr.pos-4)
+	}
+	return nil
+}
+
+// readArray reads n values of type elemType into a []T.
+func readArray[T any](hr *headerReader, n int, elemType valueType) []T {
+	values := make([]T, 0, min(n, maxPreallocatedValues))
+	for ii := 0; ii < n && hr.err == nil; ii++ {
+		value, _ := hr.value(elemType).(T)
+		values = append(values, value)
+	}
+	return values
+}
@@ -0,0 +1,305 @@
+package gguf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/sebffischer/backend/backend/dtype"
+	"github.com/stretchr/testify/require"
+	"github.com/x448/float16"
+)
+
+// ggufBuilder writes a GGUF file in memory, for tests.
+type ggufBuilder struct {
+	data       bytes.Buffer
+	numTensors int
+	numKVs     int
+	tensorsBuf bytes.Buffer
+	kvsBuf     bytes.Buffer
+}
+
+func writeString(buf *bytes.Buffer, s string) {
+	_ = binary.Write(buf, binary.LittleEndian, uint64(len(s)))
+	buf.WriteString(s)
+}
+
+func (b *ggufBuilder) addKV(key string, t valueType, value any) {
+	b.numKVs++
+	writeString(&b.kvsBuf, key)
+	_ = binary.Write(&b.kvsBuf, binary.LittleEndian, uint32(t))
+	if s, ok := value.(string); ok {
+		writeString(&b.kvsBuf, s)
+		return
+	}
+	if values, ok := value.([]string); ok {
+		_ = binary.Write(&b.kvsBuf, binary.LittleEndian, uint32(typeString))
+		_ = binary.Write(&b.kvsBuf, binary.LittleEndian, uint64(len(values)))
+		for _, s := range values {
+			writeString(&b.kvsBuf, s)
+		}
+		return
+	}
+	_ = binary.Write(&b.kvsBuf, binary.LittleEndian, value)
+}
+
+// addTensor adds a tensor, with the dimensions in GGML order (innermost first), and its raw data.
+func (b *ggufBuilder) addTensor(name string, ggmlType GGMLType, dims []uint64, data []byte) {
+	b.numTensors++
+	writeString(&b.tensorsBuf, name)
+	_ = binary.Write(&b.tensorsBuf, binary.LittleEndian, uint32(len(dims)))
+	_ = binary.Write(&b.tensorsBuf, binary.LittleEndian, dims)
+	_ = binary.Write(&b.tensorsBuf, binary.LittleEndian, uint32(ggmlType))
+	_ = binary.Write(&b.tensorsBuf, binary.LittleEndian, uint64(b.data.Len()))
+	b.data.Write(data)
+	for b.data.Len()%DefaultAlignment != 0 {
+		b.data.WriteByte(0)
+	}
+}
+
+func (b *ggufBuilder) bytes() []byte {
+	var buf bytes.Buffer
+	buf.WriteString(magic)
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(3))
+	_ = binary.Write(&buf, binary.LittleEndian, uint64(b.numTensors))
+	_ = binary.Write(&buf, binary.LittleEndian, uint64(b.numKVs))
+	buf.Write(b.kvsBuf.Bytes())
+	buf.Write(b.tensorsBuf.Bytes())
+	for buf.Len()%DefaultAlignment != 0 {
+		buf.WriteByte(0)
+	}
+	buf.Write(b.data.Bytes())
+	return buf.Bytes()
+}
+
+// nestedArrays returns a GGUF file with one metadata key/value holding the given number of nested arrays,
+// each claiming count elements.
+func nestedArrays(levels int, count uint64) []byte {
+	data := append([]byte(magic), leBytes(uint32(3))...)
+	data = append(data, leBytes(uint64(0))...)
+	data = append(data, leBytes(uint64(1))...)
+	data = append(data, leBytes(uint64(1))...)
+	data = append(data, 'a')
+	data = append(data, leBytes(uint32(typeArray))...)
+	for range levels {
+		data = append(data, leBytes(uint32(typeArray))...)
+		data = append(data, leBytes(count)...)
+	}
+	return data
+}
+
+func leBytes(value any) []byte {
+	var buf bytes.Buffer
+	_ = binary.Write(&buf, binary.LittleEndian, value)
+	return buf.Bytes()
+}
+
+func TestRead(t *testing.T) {
+	b := &ggufBuilder{}
+	b.addKV("general.architecture", typeString, "llama")
+	b.addKV("llama.block_count", typeUint32, uint32(2))
+	b.addKV("llama.rope.freq_base", typeFloat32, float32(10000))
+	b.addKV("tokenizer.ggml.tokens", typeArray, []string{"<s>", "</s>", "a"})
+
+	// F32 tensor of shape [2, 3] (GGML dims {3, 2}).
+	b.addTensor("f32", TypeF32, []uint64{3, 2}, leBytes([]float32{1, 2, 3, 4, 5, 6}))
+
+	// Q8_0 tensor with one block: d=0.5, q=-16..15.
+	q8 := leBytes(float16.Fromfloat32(0.5).Bits())
+	for ii := range 32 {
+		q8 = append(q8, byte(int8(ii-16)))
+	}
+	b.addTensor("q8_0", TypeQ8_0, []uint64{32}, q8)
+
+	// Q4_0 tensor with one block: d=2, low nibbles 0..15 and high nibbles 15..0.
+	q4 := leBytes(float16.Fromfloat32(2).Bits())
+	for ii := range 16 {
+		q4 = append(q4, byte(ii)|byte(15-ii)<<4)
+	}
+	b.addTensor("q4_0", TypeQ4_0, []uint64{32}, q4)
+
+	// F16 tensor.
+	b.addTensor("f16", TypeF16, []uint64{2}, leBytes([]uint16{float16.Fromfloat32(1.5).Bits(), float16.Fromfloat32(-2).Bits()}))
+
+	// Unsupported quantized type.
+	b.addTensor("q2_k", TypeQ2_K, []uint64{256}, make([]byte, 84))
+
+	data := b.bytes()
+	f, err := Read(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	require.Equal(t, uint32(3), f.Version)
+	require.Equal(t, "llama", f.Metadata["general.architecture"])
+	require.Equal(t, uint32(2), f.Metadata["llama.block_count"])
+	require.Equal(t, float32(10000), f.Metadata["llama.rope.freq_base"])
+	require.Equal(t, []string{"<s>", "</s>", "a"}, f.Metadata["tokenizer.ggml.tokens"])
+	require.Len(t, f.Tensors, 5)
+
+	info, found := f.Tensor("f32")
+	require.True(t, found)
+	require.Equal(t, []int{2, 3}, info.AxisLengths)
+	require.Equal(t, 6, info.NumElements())
+	require.Equal(t, "F32", info.Type.String())
+	require.False(t, info.Type.IsQuantized())
+
+	f32, err := f.ReadTensor("f32")
+	require.NoError(t, err)
+	require.Equal(t, [][]float32{{1, 2, 3}, {4, 5, 6}}, f32.Value())
+
+	q8Tensor, err := f.ReadTensor("q8_0")
+	require.NoError(t, err)
+	require.Equal(t, dtype.Float32, q8Tensor.DType())
+	q8Values := q8Tensor.Value().([]float32)
+	for ii, v := range q8Values {
+		require.Equal(t, 0.5*float32(ii-16), v)
+	}
+
+	q4Tensor, err := f.ReadTensor("q4_0")
+	require.NoError(t, err)
+	q4Values := q4Tensor.Value().([]float32)
+	for ii := range 16 {
+		require.Equal(t, 2*float32(ii-8), q4Values[ii])
+		require.Equal(t, 2*float32(7-ii), q4Values[ii+16])
+	}
+
+	f16Tensor, err := f.ReadTensor("f16")
+	require.NoError(t, err)
+	require.Equal(t, dtype.Float16, f16Tensor.DType())
+	require.Equal(t, []float16.Float16{float16.Fromfloat32(1.5), float16.Fromfloat32(-2)}, f16Tensor.Value())
+
+	asF16, err := f.ReadTensorFloat16("q8_0")
+	require.NoError(t, err)
+	require.Equal(t, dtype.Float16, asF16.DType())
+	require.Equal(t, float16.Fromfloat32(-8), asF16.At(0))
+
+	_, err = f.ReadTensor("q2_k")
+	require.ErrorContains(t, err, "unsupported type Q2_K")
+	_, err = f.ReadTensor("missing")
+	require.Error(t, err)
+}
+
+func TestReadInvalid(t *testing.T) {
+	_, err := Read(bytes.NewReader([]byte("GGML")), 4)
+	require.ErrorContains(t, err, "magic")
+
+	b := &ggufBuilder{}
+	b.addKV("general.name", typeString, "x")
+	data := b.bytes()
+	for _, size := range []int{10, 30} {
+		_, err = Read(bytes.NewReader(data[:size]), int64(size))
+		require.Error(t, err, "truncated to %d bytes", size)
+	}
+
+	// Huge count of key/values must not allocate.
+	huge := append([]byte(magic), leBytes(uint32(3))...)
+	huge = append(huge, leBytes(uint64(0))...)
+	huge = append(huge, leBytes(uint64(1)<<40)...)
+	_, err = Read(bytes.NewReader(huge), int64(len(huge)))
+	require.ErrorContains(t, err, "invalid count")
+
+	// Deeply nested arrays are rejected, and counts are checked against the size of their elements.
+	nested := nestedArrays(16384, 1)
+	_, err = Read(bytes.NewReader(nested), int64(len(nested)))
+	require.ErrorContains(t, err, "nested deeper")
+	nested = nestedArrays(4, 1<<16)
+	_, err = Read(bytes.NewReader(nested), int64(len(nested)))
+	require.ErrorContains(t, err, "invalid count")
+
+	// Tensors whose number of elements overflows, or whose data is beyond the end of the file.
+	for _, dims := range [][]uint64{{1<<31 - 1, 1<<31 - 1, 4, 2}, {65536, 65536, 65536, 65536}} {
+		b = &ggufBuilder{}
+		b.addTensor("overflow", TypeF32, dims, make([]byte, 4))
+		data = b.bytes()
+		_, err = Read(bytes.NewReader(data), int64(len(data)))
+		require.ErrorContains(t, err, "overflows", "dims %v", dims)
+	}
+	for _, tc := range []struct {
+		ggmlType GGMLType
+		dims     []uint64
+		err      string
+	}{
+		{TypeF32, []uint64{1 << 30, 4}, "beyond the end of the file"},
+		{TypeQ8_0, []uint64{1 << 20}, "beyond the end of the file"},
+		{TypeQ8_0, []uint64{33}, "not a multiple of the block size"},
+	} {
+		b = &ggufBuilder{}
+		b.addTensor("large", tc.ggmlType, tc.dims, make([]byte, 64))
+		data = b.bytes()
+		_, err = Read(bytes.NewReader(data), int64(len(data)))
+		require.ErrorContains(t, err, tc.err, "%s%v", tc.ggmlType, tc.dims)
+	}
+}
+
+func TestDequantizeK(t *testing.T) {
+	// Q4_K: d=1, dmin=0.5, all 6-bit scales=2 and mins=4 (only the first 4 sub-blocks use the simple encoding,
+	// the remaining use the packed high bits, which are 0 here, so scales=2 and mins=4 through the low nibbles).
+	block := make([]byte, 144)
+	copy(block, leBytes(float16.Fromfloat32(1).Bits()))
+	copy(block[2:], leBytes(float16.Fromfloat32(0.5).Bits()))
+	for j := range 4 {
+		block[4+j] = 2     // scales 0-3.
+		block[8+j] = 4     // mins 0-3.
+		block[12+j] = 0x42 // scales 4-7 (low nibble) and mins 4-7 (high nibble).
+	}
+	for ii := range 128 {
+		block[16+ii] = 0x31 // low nibble 1, high nibble 3.
+	}
+	out := make([]float32, 256)
+	dequantizeQ4_K(block, out)
+	for ii, v := range out {
+		q := float32(1)
+		if (ii/32)%2 == 1 {
+			q = 3
+		}
+		require.Equal(t, 2*q-0.5*4, v, "index %d", ii)
+	}
+
+	// Q6_K: all 6-bit values 33 (ql=1, qh=2), scales=3, d=0.25: y = 0.25 * 3 * (33-32).
+	block = make([]byte, 210)
+	for ii := range 128 {
+		block[ii] = 0x11
+	}
+	for ii := range 64 {
+		block[128+ii] = 0xAA
+	}
+	for ii := range 16 {
+		block[192+ii] = 3
+	}
+	copy(block[208:], leBytes(float16.Fromfloat32(0.25).Bits()))
+	dequantizeQ6_K(block, out)
+	for ii, v := range out {
+		require.Equal(t, float32(0.75), v, "index %d", ii)
+	}
+}
+
+func FuzzRead(f *testing.F) {
+	b := &ggufBuilder{}
+	b.addKV("general.architecture", typeString, "llama")
+	b.addKV("tokenizer.ggml.tokens", typeArray, []string{"a", "b"})
+	b.addTensor("f32", TypeF32, []uint64{2}, leBytes([]float32{1, 2}))
+	b.addTensor("q8_0", TypeQ8_0, []uint64{32}, make([]byte, 34))
+	f.Add(b.bytes())
+	// Axis lengths whose product overflows.
+	for _, dims := range [][]uint64{{1<<31 - 1, 1<<31 - 1, 4, 2}, {65536, 65536, 65536, 65536}} {
+		b = &ggufBuilder{}
+		b.addTensor("overflow", TypeF32, dims, make([]byte, 4))
+		f.Add(b.bytes())
+	}
+	// Deeply nested metadata arrays.
+	f.Add(nestedArrays(16384, 1))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		file, err := Read(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return
+		}
+		// Read checks the data of each tensor is within the file, so reading them is cheap.
+		for _, info := range file.Tensors {
+			require.GreaterOrEqual(t, info.NumElements(), 0, "tensor %q", info.Name)
+			tensor, err := file.ReadTensor(info.Name)
+			if err != nil {
+				continue
+			}
+			require.Equal(t, info.NumElements(), tensor.Size(), "tensor %q", info.Name)
+		}
+	})
+}
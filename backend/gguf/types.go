@@ -0,0 +1,312 @@
+package gguf
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/sebffischer/backend/backend/atype"
+	"github.com/sebffischer/backend/backend/dtype"
+	"github.com/sebffischer/backend/backend/dtype/bfloat16"
+	"github.com/sebffischer/backend/backend/tensor"
+	"github.com/x448/float16"
+)
+
+// GGMLType is the type of the values of a tensor stored in a GGUF file, see ggml_type in ggml.h.
+type GGMLType uint32
+
+const (
+	TypeF32  GGMLType = 0
+	TypeF16  GGMLType = 1
+	TypeQ4_0 GGMLType = 2
+	TypeQ4_1 GGMLType = 3
+	TypeQ5_0 GGMLType = 6
+	TypeQ5_1 GGMLType = 7
+	TypeQ8_0 GGMLType = 8
+	TypeQ8_1 GGMLType = 9
+	TypeQ2_K GGMLType = 10
+	TypeQ3_K GGMLType = 11
+	TypeQ4_K GGMLType = 12
+	TypeQ5_K GGMLType = 13
+	TypeQ6_K GGMLType = 14
+	TypeQ8_K GGMLType = 15
+	TypeI8   GGMLType = 24
+	TypeI16  GGMLType = 25
+	TypeI32  GGMLType = 26
+	TypeI64  GGMLType = 27
+	TypeF64  GGMLType = 28
+	TypeBF16 GGMLType = 30
+)
+
+// ggmlTypeInfo describes how values of a GGMLType are stored: in blocks of blockSize elements using
+// bytesPerBlock bytes.
+type ggmlTypeInfo struct {
+	name          string
+	blockSize     int
+	bytesPerBlock int
+
+	// dtype for non-quantized types, InvalidDType for quantized ones.
+	dtype dtype.DType
+
+	// dequantize a block into blockSize float32 values. nil for non-quantized types.
+	dequantize func(block []byte, out []float32)
+}
+
+// ggmlTypes lists the types that can be read. Other quantized types (e.g. Q2_K, Q3_K, Q5_K and IQ*) are
+// listed in File.Tensors, but ReadTensor returns an error for them.
+var ggmlTypes = map[GGMLType]ggmlTypeInfo{
+	TypeF32:  {name: "F32", blockSize: 1, bytesPerBlock: 4, dtype: dtype.Float32},
+	TypeF16:  {name: "F16", blockSize: 1, bytesPerBlock: 2, dtype: dtype.Float16},
+	TypeBF16: {name: "BF16", blockSize: 1, bytesPerBlock: 2, dtype: dtype.BFloat16},
+	TypeF64:  {name: "F64", blockSize: 1, bytesPerBlock: 8, dtype: dtype.Float64},
+	TypeI8:   {name: "I8", blockSize: 1, bytesPerBlock: 1, dtype: dtype.Int8},
+	TypeI16:  {name: "I16", blockSize: 1, bytesPerBlock: 2, dtype: dtype.Int16},
+	TypeI32:  {name: "I32", blockSize: 1, bytesPerBlock: 4, dtype: dtype.Int32},
+	TypeI64:  {name: "I64", blockSize: 1, bytesPerBlock: 8, dtype: dtype.Int64},
+	TypeQ4_0: {name: "Q4_0", blockSize: 32, bytesPerBlock: 18, dequantize: dequantizeQ4_0},
+	TypeQ4_1: {name: "Q4_1", blockSize: 32, bytesPerBlock: 20, dequantize: dequantizeQ4_1},
+	TypeQ5_0: {name: "Q5_0", blockSize: 32, bytesPerBlock: 22, dequantize: dequantizeQ5_0},
+	TypeQ5_1: {name: "Q5_1", blockSize: 32, bytesPerBlock: 24, dequantize: dequantizeQ5_1},
+	TypeQ8_0: {name: "Q8_0", blockSize: 32, bytesPerBlock: 34, dequantize: dequantizeQ8_0},
+	TypeQ4_K: {name: "Q4_K", blockSize: 256, bytesPerBlock: 144, dequantize: dequantizeQ4_K},
+	TypeQ6_K: {name: "Q6_K", blockSize: 256, bytesPerBlock: 210, dequantize: dequantizeQ6_K},
+}
+
+// unreadableTypeNames are the names of the known types that can't be read.
+var unreadableTypeNames = map[GGMLType]string{
+	TypeQ8_1: "Q8_1",
+	TypeQ2_K: "Q2_K",
+	TypeQ3_K: "Q3_K",
+	TypeQ5_K: "Q5_K",
+	TypeQ8_K: "Q8_K",
+}
+
+// String implements fmt.Stringer.
+func (t GGMLType) String() string {
+	if info, found := ggmlTypes[t]; found {
+		return info.name
+	}
+	if name, found := unreadableTypeNames[t]; found {
+		return name
+	}
+	return fmt.Sprintf("GGMLType(%d)", uint32(t))
+}
+
+// IsQuantized returns whether the type is a quantized format. Supported quantized formats are read
+// de-quantized into float32.
+func (t GGMLType) IsQuantized() bool {
+	info, found := ggmlTypes[t]
+	return !found || info.dequantize != nil
+}
+
+// dataRange returns the position in the file and the number of bytes of the data of the tensor, or an error if
+// it is not a whole number of blocks or if it extends past the end of the file.
+//
+// For types that can't be read, the size is unknown and numBytes is 0: only the offset is checked.
+func (f *File) dataRange(info TensorInfo) (start, numBytes int64, err error) {
+	dataSize := f.size - f.dataOffset
+	if dataSize < 0 || info.Offset > uint64(dataSize) {
+		return 0, 0, errors.Errorf("gguf: tensor %q offset %d is beyond the end of the file", info.Name, info.Offset)
+	}
+	start = f.dataOffset + int64(info.Offset)
+	typeInfo, found := ggmlTypes[info.Type]
+	if !found {
+		return start, 0, nil
+	}
+	numElements := info.NumElements()
+	if numElements%typeInfo.blockSize != 0 {
+		return 0, 0, errors.Errorf("gguf: tensor %q has %d elements, not a multiple of the block size %d of %s",
+			info.Name, numElements, typeInfo.blockSize, info.Type)
+	}
+	numBlocks := int64(numElements / typeInfo.blockSize)
+	if numBlocks > (dataSize-int64(info.Offset))/int64(typeInfo.bytesPerBlock) {
+		return 0, 0, errors.Errorf("gguf: tensor %q data (%d blocks of %d bytes at offset %d) is beyond the end of the file",
+			info.Name, numBlocks, typeInfo.bytesPerBlock, start)
+	}
+	return start, numBlocks * int64(typeInfo.bytesPerBlock), nil
+}
+
+// ReadTensor reads the tensor with the given name.
+//
+// Non-quantized types (F32, F16, BF16, F64, I8, I16, I32, I64) are read into tensors of the corresponding dtype,
+// and quantized formats (Q4_0, Q4_1, Q5_0, Q5_1, Q8_0, Q4_K, Q6_K) are de-quantized into a Float32 tensor.
+func (f *File) ReadTensor(name string) (*tensor.Tensor, error) {
+	info, found := f.Tensor(name)
+	if !found {
+		return nil, errors.Errorf("gguf: tensor %q not found", name)
+	}
+	typeInfo, found := ggmlTypes[info.Type]
+	if !found {
+		return nil, errors.Errorf("gguf: tensor %q has unsupported type %s", name, info.Type)
+	}
+	start, numBytes, err := f.dataRange(info)
+	if err != nil {
+		return nil, err
+	}
+	numElements := info.NumElements()
+	data := make([]byte, numBytes)
+	if _, err := f.r.ReadAt(data, start); err != nil {
+		return nil, errors.Wrapf(err, "gguf: failed to read tensor %q", name)
+	}
+
+	if typeInfo.dequantize == nil {
+		t, err := tensor.New(atype.Make(typeInfo.dtype, info.AxisLengths...))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := binary.Decode(data, binary.LittleEndian, t.Flat()); err != nil {
+			return nil, errors.Wrapf(err, "gguf: failed to decode tensor %q", name)
+		}
+		return t, nil
+	}
+
+	values := make([]float32, numElements)
+	for block := 0; block < numElements/typeInfo.blockSize; block++ {
+		typeInfo.dequantize(data[block*typeInfo.bytesPerBlock:(block+1)*typeInfo.bytesPerBlock],
+			values[block*typeInfo.blockSize:(block+1)*typeInfo.blockSize])
+	}
+	return tensor.FromFlat(values, info.AxisLengths...)
+}
+
+// ReadTensorFloat16 reads the tensor with the given name, like ReadTensor, and converts it to Float16.
+// It is useful to halve the memory of de-quantized weights.
+//
+// It returns an error if the tensor is not of a float or quantized type.
+func (f *File) ReadTensorFloat16(name string) (*tensor.Tensor, error) {
+	t, err := f.ReadTensor(name)
+	if err != nil {
+		return nil, err
+	}
+	var values []float16.Float16
+	switch flat := t.Flat().(type) {
+	case []float16.Float16:
+		return t, nil
+	case []float32:
+		values = make([]float16.Float16, len(flat))
+		for ii, v := range flat {
+			values[ii] = float16.Fromfloat32(v)
+		}
+	case []float64:
+		values = make([]float16.Float16, len(flat))
+		for ii, v := range flat {
+			values[ii] = float16.Fromfloat32(float32(v))
+		}
+	case []bfloat16.BFloat16:
+		values = make([]float16.Float16, len(flat))
+		for ii, v := range flat {
+			values[ii] = float16.Fromfloat32(v.Float32())
+		}
+	default:
+		return nil, errors.Errorf("gguf: tensor %q of dtype %s can't be converted to Float16", name, t.DType())
+	}
+	return tensor.FromFlat(values, t.ArrayType().AxisLengths...)
+}
+
+// f16 decodes the little-endian float16 at the start of b.
+func f16(b []byte) float32 {
+	return float16.Frombits(binary.LittleEndian.Uint16(b)).Float32()
+}
+
+// dequantizeQ4_0: 32 4-bit values with a float16 scale d: y = d * (q - 8).
+// The low nibbles hold the first 16 values, the high nibbles the last 16.
+func dequantizeQ4_0(block []byte, out []float32) {
+	d := f16(block)
+	qs := block[2:]
+	for j := 0; j < 16; j++ {
+		out[j] = d * float32(int(qs[j]&0xF)-8)
+		out[j+16] = d * float32(int(qs[j]>>4)-8)
+	}
+}
+
+// dequantizeQ4_1: 32 4-bit values with float16 scale d and minimum m: y = d * q + m.
+func dequantizeQ4_1(block []byte, out []float32) {
+	d, m := f16(block), f16(block[2:])
+	qs := block[4:]
+	for j := 0; j < 16; j++ {
+		out[j] = d*float32(qs[j]&0xF) + m
+		out[j+16] = d*float32(qs[j]>>4) + m
+	}
+}
+
+// dequantizeQ5_0: 32 5-bit values with a float16 scale d: y = d * (q - 16).
+// The 5th bit of each value is stored in a separate 32-bit mask qh.
+func dequantizeQ5_0(block []byte, out []float32) {
+	d := f16(block)
+	qh := binary.LittleEndian.Uint32(block[2:])
+	qs := block[6:]
+	for j := 0; j < 16; j++ {
+		xh0 := byte((qh>>j)<<4) & 0x10
+		xh1 := byte(qh>>(j+12)) & 0x10
+		out[j] = d * float32(int((qs[j]&0xF)|xh0)-16)
+		out[j+16] = d * float32(int((qs[j]>>4)|xh1)-16)
+	}
+}
+
+// dequantizeQ5_1: 32 5-bit values with float16 scale d and minimum m: y = d * q + m.
+func dequantizeQ5_1(block []byte, out []float32) {
+	d, m := f16(block), f16(block[2:])
+	qh := binary.LittleEndian.Uint32(block[4:])
+	qs := block[8:]
+	for j := 0; j < 16; j++ {
+		xh0 := byte((qh>>j)<<4) & 0x10
+		xh1 := byte(qh>>(j+12)) & 0x10
+		out[j] = d*float32((qs[j]&0xF)|xh0) + m
+		out[j+16] = d*float32((qs[j]>>4)|xh1) + m
+	}
+}
+
+// dequantizeQ8_0: 32 int8 values with a float16 scale d: y = d * q.
+func dequantizeQ8_0(block []byte, out []float32) {
+	d := f16(block)
+	for j, q := range block[2:34] {
+		out[j] = d * float32(int8(q))
+	}
+}
+
+// dequantizeQ4_K: super-block of 256 4-bit values in 8 sub-blocks of 32, each with a 6-bit scale and
+// 6-bit minimum (packed in 12 bytes), themselves scaled by the float16 d and dmin.
+func dequantizeQ4_K(block []byte, out []float32) {
+	d, dmin := f16(block), f16(block[2:])
+	scales := block[4:16]
+	qs := block[16:144]
+	scaleMin := func(j int) (sc, m byte) {
+		if j < 4 {
+			return scales[j] & 63, scales[j+4] & 63
+		}
+		return (scales[j+4] & 0xF) | ((scales[j-4] >> 6) << 4), (scales[j+4] >> 4) | ((scales[j] >> 6) << 4)
+	}
+	for j, is := 0, 0; j < 256; j, is = j+64, is+2 {
+		sc, m := scaleMin(is)
+		d1, m1 := d*float32(sc), dmin*float32(m)
+		sc, m = scaleMin(is + 1)
+		d2, m2 := d*float32(sc), dmin*float32(m)
+		q := qs[j/2 : j/2+32]
+		for l := 0; l < 32; l++ {
+			out[j+l] = d1*float32(q[l]&0xF) - m1
+			out[j+32+l] = d2*float32(q[l]>>4) - m2
+		}
+	}
+}
+
+// dequantizeQ6_K: super-block of 256 6-bit values in 16 sub-blocks of 16, each with an int8 scale,
+// scaled by the float16 d. The low 4 bits are stored in ql and the high 2 bits in qh.
+func dequantizeQ6_K(block []byte, out []float32) {
+	ql := block[0:128]
+	qh := block[128:192]
+	sc := block[192:208]
+	d := f16(block[208:])
+	for n := 0; n < 2; n++ {
+		ql, qh, sc, y := ql[n*64:], qh[n*32:], sc[n*8:], out[n*128:]
+		for l := 0; l < 32; l++ {
+			is := l / 16
+			q1 := int((ql[l]&0xF)|((qh[l]>>0)&3)<<4) - 32
+			q2 := int((ql[l+32]&0xF)|((qh[l]>>2)&3)<<4) - 32
+			q3 := int((ql[l]>>4)|((qh[l]>>4)&3)<<4) - 32
+			q4 := int((ql[l+32]>>4)|((qh[l]>>6)&3)<<4) - 32
+			y[l] = d * float32(int8(sc[is])) * float32(q1)
+			y[l+32] = d * float32(int8(sc[is+2])) * float32(q2)
+			y[l+64] = d * float32(int8(sc[is+4])) * float32(q3)
+			y[l+96] = d * float32(int8(sc[is+6])) * float32(q4)
+		}
+	}
+}
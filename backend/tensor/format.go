@@ -0,0 +1,245 @@
+package tensor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sebffischer/backend/backend/atype"
+	"github.com/sebffischer/backend/backend/dtype"
+)
+
+// PrintOptions configures how a Tensor is converted to string. See Tensor.StringWithOptions.
+//
+// Fields left at zero use the value of DefaultPrintOptions, so PrintOptions{LineWidth: 120} only changes
+// the line width.
+type PrintOptions struct {
+	// Precision is the number of digits after the decimal point for float (and complex) values.
+	// If negative, the shortest representation that round-trips is used.
+	// Zero uses the default: to print no decimals, use the "%.0v" format instead.
+	Precision int
+
+	// LineWidth is the maximum number of characters per line before the innermost axis is wrapped.
+	LineWidth int
+
+	// Threshold is the number of elements above which the Tensor is summarized: only the first and
+	// last EdgeItems of each axis are printed, separated by an ellipsis.
+	Threshold int
+
+	// EdgeItems is the number of items printed at the beginning and end of each axis when summarizing.
+	EdgeItems int
+}
+
+// DefaultPrintOptions are used by Tensor.String. They follow numpy's defaults.
+var DefaultPrintOptions = PrintOptions{
+	Precision: 4,
+	LineWidth: 75,
+	Threshold: 1000,
+	EdgeItems: 3,
+}
+
+// withDefaults returns opts with its zero fields set to the value in DefaultPrintOptions.
+func (opts PrintOptions) withDefaults() PrintOptions {
+	if opts.Precision == 0 {
+		opts.Precision = DefaultPrintOptions.Precision
+	}
+	if opts.LineWidth == 0 {
+		opts.LineWidth = DefaultPrintOptions.LineWidth
+	}
+	if opts.Threshold == 0 {
+		opts.Threshold = DefaultPrintOptions.Threshold
+	}
+	if opts.EdgeItems == 0 {
+		opts.EdgeItems = DefaultPrintOptions.EdgeItems
+	}
+	return opts
+}
+
+// String implements fmt.Stringer, using DefaultPrintOptions.
+func (t *Tensor) String() string {
+	return t.StringWithOptions(DefaultPrintOptions)
+}
+
+// Format implements fmt.Formatter. It supports the verbs %v and %s, and the precision
+// (e.g. "%.2v") overrides DefaultPrintOptions.Precision.
+func (t *Tensor) Format(s fmt.State, verb rune) {
+	if verb != 'v' && verb != 's' {
+		_, _ = fmt.Fprintf(s, "%%!%c(*tensor.Tensor=%s)", verb, t.arrayType)
+		return
+	}
+	opts := DefaultPrintOptions.withDefaults()
+	if precision, ok := s.Precision(); ok {
+		opts.Precision = precision
+	}
+	_, _ = s.Write([]byte(t.stringWithOptions(opts)))
+}
+
+// StringWithOptions pretty-prints the Tensor in a numpy-like format: the array type in the first line,
+// followed by the elements, right-aligned, one row of the innermost axis per line.
+//
+// Tensors with more than opts.Threshold elements are summarized, printing only the "corners" of each axis.
+// Zero fields of opts use the value of DefaultPrintOptions.
+//
+// Example:
+//
+//	(Float32)[2 3]
+//	[[1.0000 2.0000 3.0000]
+//	 [4.0000 5.0000 6.0000]]
+func (t *Tensor) StringWithOptions(opts PrintOptions) string {
+	return t.stringWithOptions(opts.withDefaults())
+}
+
+// stringWithOptions implements StringWithOptions, with all options set.
+func (t *Tensor) stringWithOptions(opts PrintOptions) string {
+	p := &printer{t: t, opts: opts, summarize: t.Size() > opts.Threshold}
+	if t.arrayType.IsScalar() {
+		return fmt.Sprintf("%s %s", t.arrayType, p.formatElement(t.At()))
+	}
+
+	// Pre-format all elements printed, to find the width used to align them.
+	p.formatted = make(map[int]string)
+	indices := make([]int, t.NumAxes())
+	p.collect(0, indices)
+
+	var sb strings.Builder
+	sb.WriteString(t.arrayType.String())
+	sb.WriteString("\n")
+	p.sb = &sb
+	p.render(0, indices)
+	return sb.String()
+}
+
+// printer holds the state of the conversion of a Tensor to string.
+type printer struct {
+	t         *Tensor
+	opts      PrintOptions
+	summarize bool
+	formatted map[int]string // Formatted elements indexed by flat index.
+	width     int
+	sb        *strings.Builder
+}
+
+// ellipsis is used in place of the elements omitted when summarizing.
+const ellipsis = "..."
+
+// shownIndices returns the indices of the given axis to print, with -1 representing the ellipsis.
+func (p *printer) shownIndices(axis int) []int {
+	length := p.t.arrayType.AxisLengths[axis]
+	edge := p.opts.EdgeItems
+	if p.summarize && length > 2*edge {
+		shown := make([]int, 0, 2*edge+1)
+		for ii := 0; ii < edge; ii++ {
+			shown = append(shown, ii)
+		}
+		shown = append(shown, -1)
+		for ii := length - edge; ii < length; ii++ {
+			shown = append(shown, ii)
+		}
+		return shown
+	}
+	shown := make([]int, length)
+	for ii := range shown {
+		shown[ii] = ii
+	}
+	return shown
+}
+
+// collect formats all elements that will be printed, and records the maximum width.
+func (p *printer) collect(axis int, indices []int) {
+	for _, idx := range p.shownIndices(axis) {
+		if idx < 0 {
+			continue
+		}
+		indices[axis] = idx
+		if axis < p.t.NumAxes()-1 {
+			p.collect(axis+1, indices)
+			continue
+		}
+		str := p.formatElement(p.t.At(indices...))
+		p.formatted[p.t.flatIndex(indices)] = str
+		p.width = max(p.width, len(str))
+	}
+}
+
+// render writes the elements of the sub-array starting at axis.
+func (p *printer) render(axis int, indices []int) {
+	numAxes := p.t.NumAxes()
+	p.sb.WriteString("[")
+	shown := p.shownIndices(axis)
+	if axis == numAxes-1 {
+		lineLen := axis + 1
+		for ii, idx := range shown {
+			item := ellipsis
+			if idx >= 0 {
+				indices[axis] = idx
+				item = fmt.Sprintf("%*s", p.width, p.formatted[p.t.flatIndex(indices)])
+			}
+			if ii > 0 {
+				if lineLen+1+len(item)+1 > p.opts.LineWidth {
+					// Wrap line, aligned with the first element.
+					p.sb.WriteString("\n")
+					p.sb.WriteString(strings.Repeat(" ", axis+1))
+					lineLen = axis + 1
+				} else {
+					p.sb.WriteString(" ")
+					lineLen++
+				}
+			}
+			p.sb.WriteString(item)
+			lineLen += len(item)
+		}
+		p.sb.WriteString("]")
+		return
+	}
+
+	// Sub-arrays are separated by a new line, plus one blank line per extra axis.
+	separator := "\n" + strings.Repeat("\n", numAxes-axis-2) + strings.Repeat(" ", axis+1)
+	for ii, idx := range shown {
+		if ii > 0 {
+			p.sb.WriteString(separator)
+		}
+		if idx < 0 {
+			p.sb.WriteString(ellipsis)
+			continue
+		}
+		indices[axis] = idx
+		p.render(axis+1, indices)
+	}
+	p.sb.WriteString("]")
+}
+
+// formatElement converts one element to string according to the options.
+func (p *printer) formatElement(value any) string {
+	switch v := value.(type) {
+	case bool:
+		return strconv.FormatBool(v)
+	case complex64:
+		return p.formatComplex(complex128(v))
+	case complex128:
+		return p.formatComplex(v)
+	}
+	dt := p.t.DType()
+	if dt.IsInt() {
+		return fmt.Sprintf("%d", value)
+	}
+	return p.formatFloat(atype.ConvertTo[float64](value))
+}
+
+func (p *printer) formatFloat(v float64) string {
+	if p.opts.Precision < 0 {
+		bitSize := 64
+		if dt := p.t.DType(); dt == dtype.Float32 || dt == dtype.Complex64 {
+			bitSize = 32
+		}
+		return strconv.FormatFloat(v, 'g', -1, bitSize)
+	}
+	return strconv.FormatFloat(v, 'f', p.opts.Precision, 64)
+}
+
+func (p *printer) formatComplex(v complex128) string {
+	imagStr := p.formatFloat(imag(v))
+	if !strings.HasPrefix(imagStr, "-") {
+		imagStr = "+" + imagStr
+	}
+	return p.formatFloat(real(v)) + imagStr + "i"
+}
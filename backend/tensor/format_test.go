@@ -0,0 +1,74 @@
+package tensor
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/sebffischer/backend/backend/atype"
+	"github.com/sebffischer/backend/backend/dtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestString(t *testing.T) {
+	tensor, err := FromValue([][]float32{{1, 2, 3}, {4, -5.5, 6}})
+	require.NoError(t, err)
+	require.Equal(t, "(Float32)[2 3]\n"+
+		"[[ 1.0000  2.0000  3.0000]\n"+
+		" [ 4.0000 -5.5000  6.0000]]", tensor.String())
+	require.Equal(t, "(Float32)[2 3]\n"+
+		"[[   1    2    3]\n"+
+		" [   4 -5.5    6]]", tensor.StringWithOptions(PrintOptions{Precision: -1, LineWidth: 75, Threshold: 1000}))
+	require.Equal(t, "(Float32)[2 3]\n"+
+		"[[ 1.0  2.0  3.0]\n"+
+		" [ 4.0 -5.5  6.0]]", fmt.Sprintf("%.1v", tensor))
+
+	// Scalars, ints, bools and complex numbers.
+	tensor, err = FromValue(int32(7))
+	require.NoError(t, err)
+	require.Equal(t, "(Int32) 7", tensor.String())
+
+	tensor, err = FromValue([][][]bool{{{true}, {false}}, {{false}, {true}}})
+	require.NoError(t, err)
+	require.Equal(t, "(Bool)[2 2 1]\n"+
+		"[[[ true]\n"+
+		"  [false]]\n"+
+		"\n"+
+		" [[false]\n"+
+		"  [ true]]]", tensor.String())
+
+	tensor, err = FromValue([]complex64{1 + 2i, -1 - 0.5i})
+	require.NoError(t, err)
+	require.Equal(t, "(Complex64)[2]\n[ 1.0+2.0i -1.0-0.5i]", tensor.StringWithOptions(PrintOptions{Precision: 1, LineWidth: 75, Threshold: 10}))
+}
+
+func TestStringSummarizeAndWrap(t *testing.T) {
+	flat := make([]int64, 100*100)
+	for ii := range flat {
+		flat[ii] = int64(ii)
+	}
+	tensor, err := FromFlat(flat, 100, 100)
+	require.NoError(t, err)
+	require.Equal(t, "(Int64)[100 100]\n"+
+		"[[   0    1    2 ...   97   98   99]\n"+
+		" [ 100  101  102 ...  197  198  199]\n"+
+		" [ 200  201  202 ...  297  298  299]\n"+
+		" ...\n"+
+		" [9700 9701 9702 ... 9797 9798 9799]\n"+
+		" [9800 9801 9802 ... 9897 9898 9899]\n"+
+		" [9900 9901 9902 ... 9997 9998 9999]]", tensor.String())
+
+	tensor, err = New(atype.Make(dtype.Int8, 12))
+	require.NoError(t, err)
+	require.Equal(t, "(Int8)[12]\n"+
+		"[0 0 0 0 0 0 0 0 0\n"+
+		" 0 0 0]", tensor.StringWithOptions(PrintOptions{LineWidth: 20}))
+
+	// Zero options use the defaults.
+	tensor, err = FromFlat(flat, 100, 100)
+	require.NoError(t, err)
+	require.Equal(t, tensor.String(), tensor.StringWithOptions(PrintOptions{}))
+	tensor, err = FromValue([]float32{1.5, 2})
+	require.NoError(t, err)
+	require.Equal(t, "(Float32)[2]\n[1.5000 2.0000]", tensor.StringWithOptions(PrintOptions{}))
+	require.Equal(t, "(Float32)[2]\n[2 2]", fmt.Sprintf("%.0v", tensor))
+}
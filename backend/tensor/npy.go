@@ -0,0 +1,279 @@
+package tensor
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sebffischer/backend/backend/atype"
+	"github.com/sebffischer/backend/backend/dtype"
+)
+
+// This file implements reading and writing of the numpy NPY and NPZ formats,
+// see https://numpy.org/doc/stable/reference/generated/numpy.lib.format.html
+
+// npyMagic is the prefix of every NPY file.
+const npyMagic = "\x93NUMPY"
+
+// npyDescrs maps dtypes to the numpy type strings ("descr") used when saving.
+//
+// numpy has no native bfloat16 or float8 types: the names used are the ones registered by the
+// ml_dtypes Python package, so `import ml_dtypes` is required before loading these files in Python.
+var npyDescrs = map[dtype.DType]string{
+	dtype.Bool:       "|b1",
+	dtype.Int8:       "|i1",
+	dtype.Int16:      "<i2",
+	dtype.Int32:      "<i4",
+	dtype.Int64:      "<i8",
+	dtype.Uint8:      "|u1",
+	dtype.Uint16:     "<u2",
+	dtype.Uint32:     "<u4",
+	dtype.Uint64:     "<u8",
+	dtype.Float16:    "<f2",
+	dtype.Float32:    "<f4",
+	dtype.Float64:    "<f8",
+	dtype.Complex64:  "<c8",
+	dtype.Complex128: "<c16",
+	dtype.BFloat16:   "bfloat16",
+	dtype.F8E4M3FN:   "float8_e4m3fn",
+	dtype.F8E5M2:     "float8_e5m2",
+}
+
+// dtypeFromNpyDescr returns the dtype and byte order for a numpy type string.
+func dtypeFromNpyDescr(descr string) (dtype.DType, binary.ByteOrder, error) {
+	var byteOrder binary.ByteOrder = binary.LittleEndian
+	key := descr
+	if len(descr) > 1 {
+		switch descr[0] {
+		case '>':
+			byteOrder = binary.BigEndian
+			key = "<" + descr[1:]
+		case '=', '<', '|':
+			key = "<" + descr[1:]
+		}
+	}
+	for dt, npyDescr := range npyDescrs {
+		if npyDescr == descr || strings.Replace(npyDescr, "|", "<", 1) == key {
+			return dt, byteOrder, nil
+		}
+	}
+	return dtype.InvalidDType, nil, errors.Errorf("numpy dtype %q not supported", descr)
+}
+
+// SaveNpy writes the Tensor to w in numpy's NPY format (version 1.0, or 2.0 if the header is too large).
+// Non-contiguous views are saved in row-major order.
+func SaveNpy(w io.Writer, t *Tensor) error {
+	descr, found := npyDescrs[t.DType()]
+	if !found {
+		return errors.Errorf("SaveNpy: dtype %s not supported by the NPY format", t.DType())
+	}
+	shapeStrs := make([]string, t.NumAxes())
+	for ii, length := range t.arrayType.AxisLengths {
+		shapeStrs[ii] = strconv.Itoa(length)
+	}
+	shape := strings.Join(shapeStrs, ", ")
+	if t.NumAxes() == 1 {
+		shape += ","
+	}
+	header := fmt.Sprintf("{'descr': '%s', 'fortran_order': False, 'shape': (%s), }", descr, shape)
+
+	// Pad header with spaces and a final "\n", so the data is aligned to 64 bytes.
+	version, lenBytes := byte(1), 2
+	padding := 64 - (len(npyMagic)+2+lenBytes+len(header)+1)%64
+	if len(header)+padding+1 > 0xFFFF {
+		version, lenBytes = 2, 4
+		padding = 64 - (len(npyMagic)+2+lenBytes+len(header)+1)%64
+	}
+	if padding == 64 {
+		padding = 0
+	}
+	header += strings.Repeat(" ", padding) + "\n"
+
+	var buf bytes.Buffer
+	buf.WriteString(npyMagic)
+	buf.Write([]byte{version, 0})
+	if version == 1 {
+		_ = binary.Write(&buf, binary.LittleEndian, uint16(len(header)))
+	} else {
+		_ = binary.Write(&buf, binary.LittleEndian, uint32(len(header)))
+	}
+	buf.WriteString(header)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return errors.Wrap(err, "SaveNpy: failed to write header")
+	}
+	if err := binary.Write(w, binary.LittleEndian, t.Flat()); err != nil {
+		return errors.Wrapf(err, "SaveNpy: failed to write data for %s", t.arrayType)
+	}
+	return nil
+}
+
+var (
+	npyDescrRegexp        = regexp.MustCompile(`'descr'\s*:\s*'([^']*)'`)
+	npyFortranOrderRegexp = regexp.MustCompile(`'fortran_order'\s*:\s*(True|False)`)
+	npyShapeRegexp        = regexp.MustCompile(`'shape'\s*:\s*\(([^)]*)\)`)
+)
+
+// LoadNpy reads a Tensor in numpy's NPY format from r. Arrays stored in Fortran order or big-endian are
+// converted to row-major order and native (little-endian) byte order.
+func LoadNpy(r io.Reader) (*Tensor, error) {
+	prefix := make([]byte, len(npyMagic)+2)
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		return nil, errors.Wrap(err, "LoadNpy: failed to read header")
+	}
+	if string(prefix[:len(npyMagic)]) != npyMagic {
+		return nil, errors.New("LoadNpy: invalid NPY file, magic string not found")
+	}
+	var headerLen int64
+	switch version := prefix[len(npyMagic)]; version {
+	case 1:
+		var l uint16
+		if err := binary.Read(r, binary.LittleEndian, &l); err != nil {
+			return nil, errors.Wrap(err, "LoadNpy: failed to read header")
+		}
+		headerLen = int64(l)
+	case 2, 3:
+		var l uint32
+		if err := binary.Read(r, binary.LittleEndian, &l); err != nil {
+			return nil, errors.Wrap(err, "LoadNpy: failed to read header")
+		}
+		headerLen = int64(l)
+	default:
+		return nil, errors.Errorf("LoadNpy: unsupported NPY format version %d", version)
+	}
+	// Like the data, the header buffer only grows with the bytes actually read.
+	header, err := io.ReadAll(io.LimitReader(r, headerLen))
+	if err != nil {
+		return nil, errors.Wrap(err, "LoadNpy: failed to read header")
+	}
+	if int64(len(header)) != headerLen {
+		return nil, errors.Errorf("LoadNpy: truncated header, got %d bytes, wanted %d", len(header), headerLen)
+	}
+
+	descrMatch := npyDescrRegexp.FindSubmatch(header)
+	fortranMatch := npyFortranOrderRegexp.FindSubmatch(header)
+	shapeMatch := npyShapeRegexp.FindSubmatch(header)
+	if descrMatch == nil || fortranMatch == nil || shapeMatch == nil {
+		return nil, errors.Errorf("LoadNpy: failed to parse header %q", header)
+	}
+	dt, byteOrder, err := dtypeFromNpyDescr(string(descrMatch[1]))
+	if err != nil {
+		return nil, errors.WithMessage(err, "LoadNpy")
+	}
+	var axisLengths []int
+	for _, lengthStr := range strings.Split(string(shapeMatch[1]), ",") {
+		lengthStr = strings.TrimSpace(lengthStr)
+		if lengthStr == "" {
+			continue
+		}
+		length, err := strconv.Atoi(lengthStr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "LoadNpy: invalid shape in header %q", header)
+		}
+		axisLengths = append(axisLengths, length)
+	}
+	fortranOrder := string(fortranMatch[1]) == "True"
+	if fortranOrder {
+		slices.Reverse(axisLengths)
+	}
+
+	arrayType, err := atype.MakeE(dt, axisLengths...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "LoadNpy")
+	}
+	numBytes, err := npyDataSize(arrayType)
+	if err != nil {
+		return nil, err
+	}
+	if lr, ok := r.(interface{ Len() int }); ok && lr.Len() < numBytes {
+		return nil, errors.Errorf("LoadNpy: %s requires %d bytes of data, only %d available", arrayType, numBytes, lr.Len())
+	}
+	// Read the data before allocating the tensor: io.ReadAll grows the buffer as data arrives, so a corrupt
+	// header with huge axis lengths fails on the truncated data instead of allocating its claimed size.
+	data, err := io.ReadAll(io.LimitReader(r, int64(numBytes)))
+	if err != nil {
+		return nil, errors.Wrapf(err, "LoadNpy: failed to read data for %s", arrayType)
+	}
+	if len(data) != numBytes {
+		return nil, errors.Errorf("LoadNpy: truncated data for %s, got %d bytes, wanted %d", arrayType, len(data), numBytes)
+	}
+	t, err := New(arrayType)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := binary.Decode(data, byteOrder, t.flat); err != nil {
+		return nil, errors.Wrapf(err, "LoadNpy: failed to decode data for %s", arrayType)
+	}
+	if fortranOrder {
+		// Data was read with axes reversed: transpose back and make it row-major.
+		if t, err = t.T(); err != nil {
+			return nil, err
+		}
+		t = t.Contiguous()
+	}
+	return t, nil
+}
+
+// npyDataSize returns the number of bytes of data of an NPY file with the given array type, or an error
+// if it overflows an int.
+func npyDataSize(arrayType atype.ArrayType) (int, error) {
+	numBytes := arrayType.DType.Size()
+	for _, length := range arrayType.AxisLengths {
+		if length != 0 && numBytes > math.MaxInt/length {
+			return 0, errors.Errorf("LoadNpy: size of %s overflows", arrayType)
+		}
+		numBytes *= length
+	}
+	return numBytes, nil
+}
+
+// SaveNpz writes the tensors to w in numpy's NPZ format (a zip file of NPY files, not compressed,
+// like numpy.savez), one entry per name.
+func SaveNpz(w io.Writer, tensors map[string]*Tensor) error {
+	zipWriter := zip.NewWriter(w)
+	names := make([]string, 0, len(tensors))
+	for name := range tensors {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		entry, err := zipWriter.CreateHeader(&zip.FileHeader{Name: name + ".npy", Method: zip.Store})
+		if err != nil {
+			return errors.Wrapf(err, "SaveNpz: failed to create entry for %q", name)
+		}
+		if err := SaveNpy(entry, tensors[name]); err != nil {
+			return errors.WithMessagef(err, "SaveNpz: entry %q", name)
+		}
+	}
+	return errors.Wrap(zipWriter.Close(), "SaveNpz: failed to finalize zip file")
+}
+
+// LoadNpz reads all tensors of a numpy NPZ file with the given size from r, indexed by their names.
+func LoadNpz(r io.ReaderAt, size int64) (map[string]*Tensor, error) {
+	zipReader, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, errors.Wrap(err, "LoadNpz: invalid zip file")
+	}
+	tensors := make(map[string]*Tensor, len(zipReader.File))
+	for _, file := range zipReader.File {
+		name := strings.TrimSuffix(file.Name, ".npy")
+		entry, err := file.Open()
+		if err != nil {
+			return nil, errors.Wrapf(err, "LoadNpz: failed to open entry %q", file.Name)
+		}
+		t, err := LoadNpy(entry)
+		_ = entry.Close()
+		if err != nil {
+			return nil, errors.WithMessagef(err, "LoadNpz: entry %q", file.Name)
+		}
+		tensors[name] = t
+	}
+	return tensors, nil
+}
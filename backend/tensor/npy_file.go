@@ -0,0 +1,59 @@
+package tensor
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// SaveNpyFile saves the Tensor to the file in path in numpy's NPY format. See SaveNpy.
+func SaveNpyFile(path string, t *Tensor) (err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.Wrapf(err, "SaveNpyFile(%q)", path)
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil && closeErr != nil {
+			err = errors.Wrapf(closeErr, "SaveNpyFile(%q)", path)
+		}
+	}()
+	return SaveNpy(f, t)
+}
+
+// LoadNpyFile loads a Tensor from the file in path in numpy's NPY format. See LoadNpy.
+func LoadNpyFile(path string) (*Tensor, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "LoadNpyFile(%q)", path)
+	}
+	defer func() { _ = f.Close() }()
+	return LoadNpy(f)
+}
+
+// SaveNpzFile saves the tensors to the file in path in numpy's NPZ format. See SaveNpz.
+func SaveNpzFile(path string, tensors map[string]*Tensor) (err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.Wrapf(err, "SaveNpzFile(%q)", path)
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil && closeErr != nil {
+			err = errors.Wrapf(closeErr, "SaveNpzFile(%q)", path)
+		}
+	}()
+	return SaveNpz(f, tensors)
+}
+
+// LoadNpzFile loads all tensors from the file in path in numpy's NPZ format. See LoadNpz.
+func LoadNpzFile(path string) (map[string]*Tensor, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "LoadNpzFile(%q)", path)
+	}
+	defer func() { _ = f.Close() }()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, errors.Wrapf(err, "LoadNpzFile(%q)", path)
+	}
+	return LoadNpz(f, info.Size())
+}
@@ -0,0 +1,174 @@
+package tensor
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sebffischer/backend/backend/dtype"
+	"github.com/sebffischer/backend/backend/dtype/bfloat16"
+	"github.com/sebffischer/backend/backend/dtype/float8"
+	"github.com/stretchr/testify/require"
+	"github.com/x448/float16"
+)
+
+func TestSaveNpy(t *testing.T) {
+	tensor, err := FromValue([][]float32{{1, 2, 3}, {4, 5, 6}})
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, SaveNpy(&buf, tensor))
+
+	// Header as written by numpy.save.
+	wantHeader := "\x93NUMPY\x01\x00\x76\x00{'descr': '<f4', 'fortran_order': False, 'shape': (2, 3), }"
+	require.Equal(t, 128+6*4, buf.Len())
+	require.Equal(t, wantHeader, buf.String()[:len(wantHeader)])
+	require.Equal(t, byte('\n'), buf.Bytes()[127])
+
+	loaded, err := LoadNpy(&buf)
+	require.NoError(t, err)
+	require.Equal(t, tensor.Value(), loaded.Value())
+}
+
+func TestNpyRoundTrip(t *testing.T) {
+	values := []any{
+		true,
+		[]bool{true, false},
+		[]int8{-1, 2},
+		[][]int16{{-1}, {2}},
+		[]int32{-1, 2},
+		[]int64{-1, 2},
+		[]uint8{1, 2},
+		[]uint16{1, 2},
+		[]uint32{1, 2},
+		[]uint64{1, 2},
+		[]float16.Float16{float16.Fromfloat32(1.5)},
+		[]bfloat16.BFloat16{bfloat16.FromFloat32(-2.5)},
+		[]float8.E4M3FN{float8.E4M3FNFromFloat32(3)},
+		[]float8.E5M2{float8.E5M2FromFloat32(4)},
+		[][][]float32{{{1, 2}}},
+		[]float64{1, 2},
+		[]complex64{1 + 2i},
+		[]complex128{3 - 4i},
+	}
+	for _, value := range values {
+		tensor, err := FromValue(value)
+		require.NoError(t, err)
+		var buf bytes.Buffer
+		require.NoError(t, SaveNpy(&buf, tensor))
+		require.Zero(t, (buf.Len()-int(tensor.arrayType.Memory()))%64, "data should be 64-bytes aligned")
+		loaded, err := LoadNpy(&buf)
+		require.NoError(t, err, "dtype=%s", tensor.DType())
+		require.True(t, tensor.ArrayType().Equal(loaded.ArrayType()))
+		require.Equal(t, value, loaded.Value())
+	}
+
+	// Non-contiguous views are saved in row-major order.
+	tensor, err := FromValue([][]int32{{1, 2}, {3, 4}})
+	require.NoError(t, err)
+	transposed, err := tensor.T()
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, SaveNpy(&buf, transposed))
+	loaded, err := LoadNpy(&buf)
+	require.NoError(t, err)
+	require.Equal(t, [][]int32{{1, 3}, {2, 4}}, loaded.Value())
+
+	// Unsupported dtypes.
+	_, _, err = dtypeFromNpyDescr("<U8")
+	require.Error(t, err)
+	got, _, err := dtypeFromNpyDescr("|b1")
+	require.NoError(t, err)
+	require.Equal(t, dtype.Bool, got)
+}
+
+func TestLoadNpyFortranAndBigEndian(t *testing.T) {
+	header := "{'descr': '>i2', 'fortran_order': True, 'shape': (2, 3), }"
+	var buf bytes.Buffer
+	buf.WriteString("\x93NUMPY\x01\x00")
+	buf.Write([]byte{byte(len(header)), 0})
+	buf.WriteString(header)
+	// Column-major order of [[1, 2, 3], [4, 5, 6]], in big-endian.
+	buf.Write([]byte{0, 1, 0, 4, 0, 2, 0, 5, 0, 3, 0, 6})
+	loaded, err := LoadNpy(&buf)
+	require.NoError(t, err)
+	require.True(t, loaded.IsContiguous())
+	require.Equal(t, [][]int16{{1, 2, 3}, {4, 5, 6}}, loaded.Value())
+
+	_, err = LoadNpy(bytes.NewReader([]byte("not a npy file")))
+	require.Error(t, err)
+
+	// Header length larger than the file.
+	_, err = LoadNpy(bytes.NewReader([]byte("\x93NUMPY\x02\x00\xff\xff\xff\xff{}")))
+	require.ErrorContains(t, err, "truncated header")
+
+	// Corrupt shapes: negative, overflowing or larger than the data must fail without allocating.
+	for _, shape := range []string{"(-1, 2)", "(3037000500, 3037000500)", "(4611686018427387904, 4)", "(1000000000,)"} {
+		data := npyWithShape("<f4", shape)
+		_, err = LoadNpy(bytes.NewReader(data))
+		require.Error(t, err, "shape %s", shape)
+		_, err = LoadNpy(bufio.NewReader(bytes.NewReader(data)))
+		require.Error(t, err, "shape %s", shape)
+	}
+}
+
+// npyWithShape returns an NPY v1 file with the given descr and shape, and 8 bytes of data.
+func npyWithShape(descr, shape string) []byte {
+	header := fmt.Sprintf("{'descr': '%s', 'fortran_order': False, 'shape': %s, }", descr, shape)
+	var buf bytes.Buffer
+	buf.WriteString("\x93NUMPY\x01\x00")
+	buf.Write([]byte{byte(len(header)), 0})
+	buf.WriteString(header)
+	buf.Write(make([]byte, 8))
+	return buf.Bytes()
+}
+
+func TestNpz(t *testing.T) {
+	a, err := FromValue([]float32{1, 2})
+	require.NoError(t, err)
+	b, err := FromValue([][]int64{{3}})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "arrays.npz")
+	require.NoError(t, SaveNpzFile(path, map[string]*Tensor{"a": a, "b": b}))
+	loaded, err := LoadNpzFile(path)
+	require.NoError(t, err)
+	require.Len(t, loaded, 2)
+	require.Equal(t, a.Value(), loaded["a"].Value())
+	require.Equal(t, b.Value(), loaded["b"].Value())
+
+	path = filepath.Join(t.TempDir(), "a.npy")
+	require.NoError(t, SaveNpyFile(path, a))
+	loadedA, err := LoadNpyFile(path)
+	require.NoError(t, err)
+	require.Equal(t, a.Value(), loadedA.Value())
+}
+
+func FuzzLoadNpy(f *testing.F) {
+	for _, value := range []any{[]float32{1, 2}, [][]int16{{1}, {2}}, true} {
+		tensor, err := FromValue(value)
+		require.NoError(f, err)
+		var buf bytes.Buffer
+		require.NoError(f, SaveNpy(&buf, tensor))
+		f.Add(buf.Bytes())
+	}
+	// Huge header length.
+	f.Add([]byte("\x93NUMPY\x02\x00\xff\xff\xff\xff{}"))
+	// Shapes whose size overflows.
+	f.Add(npyWithShape("<f4", "(3037000500, 3037000500)"))
+	f.Add(npyWithShape("|u1", "(4611686018427387904, 4)"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		tensor, err := LoadNpy(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		require.Equal(t, tensor.Size(), reflect.ValueOf(tensor.Flat()).Len(), "storage doesn't match %s", tensor.ArrayType())
+		// Anything loaded must save and load back to the same values.
+		var buf bytes.Buffer
+		require.NoError(t, SaveNpy(&buf, tensor))
+		loaded, err := LoadNpy(&buf)
+		require.NoError(t, err)
+		require.True(t, loaded.ArrayType().Equal(tensor.ArrayType()))
+	})
+}
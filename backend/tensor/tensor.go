@@ -0,0 +1,261 @@
+// Package tensor defines Tensor, a concrete host-resident array: an atype.ArrayType plus
+// the backing data stored as a flat Go slice, in row-major order unless it is a view (see Tensor.Slice).
+//
+// It is the standard currency to move values between user code (Go scalars and nested slices)
+// and backends.
+//
+// Example:
+//
+//	t, err := tensor.FromValue([][]float32{{1, 2, 3}, {4, 5, 6}})  // Array type (Float32)[2 3]
+//	x := t.At(1, 2)                                               // float32(6)
+//	v := t.Value().([][]float32)                                  // A copy of the original value.
+package tensor
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+	"github.com/sebffischer/backend/backend/atype"
+	"github.com/sebffischer/backend/backend/dtype"
+)
+
+// Tensor is a host-resident array.
+//
+// The data is stored in a flat slice `[]T`, where T is the Go type of the dtype (see dtype.DType.GoType).
+// Tensors are created contiguous in row-major order, but views created with Slice or T share the storage
+// with the original Tensor and use a strided layout (see atype.Layout).
+type Tensor struct {
+	arrayType atype.ArrayType
+
+	// flat is a []T slice holding the data.
+	flat any
+
+	// layout of the elements in flat.
+	layout atype.Layout
+}
+
+// New returns a new Tensor of the given array type with all elements set to zero.
+//
+// It returns an error if the array type is invalid or its dtype has no corresponding Go type.
+func New(arrayType atype.ArrayType) (*Tensor, error) {
+	if err := checkArrayType(arrayType); err != nil {
+		return nil, err
+	}
+	size := arrayType.Size()
+	flat := reflect.MakeSlice(reflect.SliceOf(arrayType.DType.GoType()), size, size)
+	return newContiguous(arrayType, flat.Interface()), nil
+}
+
+// newContiguous returns a Tensor using flat as storage in row-major order.
+func newContiguous(arrayType atype.ArrayType, flat any) *Tensor {
+	arrayType = arrayType.Clone()
+	return &Tensor{arrayType: arrayType, flat: flat, layout: atype.RowMajorLayout(arrayType)}
+}
+
+// checkArrayType returns an error if a Tensor can't hold values of the given array type.
+func checkArrayType(arrayType atype.ArrayType) error {
+	if !arrayType.Ok() {
+		return errors.Errorf("tensor: invalid array type %s", arrayType)
+	}
+	if !arrayType.DType.IsSupported() {
+		return errors.Errorf("tensor: dtype %s not supported for host tensors", arrayType.DType)
+	}
+	return nil
+}
+
+// FromValue returns a new Tensor holding a copy of value, which can be a Go scalar or a (multi-level) slice
+// of a supported scalar type. The array type is derived with atype.FromAnyValue.
+//
+// Example:
+//
+//	t, err := tensor.FromValue([][]int32{{1, 2}, {3, 4}}) // Array type (Int32)[2 2]
+func FromValue(value any) (*Tensor, error) {
+	arrayType, err := atype.FromAnyValue(value)
+	if err != nil {
+		return nil, errors.WithMessage(err, "tensor.FromValue")
+	}
+	t, err := New(arrayType)
+	if err != nil {
+		return nil, err
+	}
+	flat := reflect.ValueOf(t.flat)
+	var pos int
+	copyValueToFlat(reflect.ValueOf(value), flat, &pos)
+	return t, nil
+}
+
+// copyValueToFlat recursively copies the (multi-level) slice v to flat, starting at *pos.
+//
+// Elements whose Go type differs from the one of flat (e.g. int for an Int64 tensor) are converted.
+func copyValueToFlat(v, flat reflect.Value, pos *int) {
+	elemType := flat.Type().Elem()
+	if v.Kind() != reflect.Slice {
+		flat.Index(*pos).Set(v.Convert(elemType))
+		*pos++
+		return
+	}
+	if v.Type().Elem().Kind() != reflect.Slice {
+		if v.Type().Elem() == elemType {
+			*pos += reflect.Copy(flat.Slice(*pos, flat.Len()), v)
+			return
+		}
+		for ii := 0; ii < v.Len(); ii++ {
+			flat.Index(*pos).Set(v.Index(ii).Convert(elemType))
+			*pos++
+		}
+		return
+	}
+	for ii := 0; ii < v.Len(); ii++ {
+		copyValueToFlat(v.Index(ii), flat, pos)
+	}
+}
+
+// FromFlat returns a Tensor with the given axis lengths that uses flat as its storage, without copying.
+// Changes to flat are reflected in the Tensor and vice-versa.
+//
+// It returns an error if len(flat) doesn't match the number of elements of the axis lengths.
+func FromFlat[T dtype.Supported](flat []T, axisLengths ...int) (*Tensor, error) {
+	arrayType, err := atype.MakeE(dtype.FromGenericsType[T](), axisLengths...)
+	if err != nil {
+		return nil, err
+	}
+	return FromFlatAny(arrayType, flat)
+}
+
+// FromFlatAny is the non-generic version of FromFlat: flat must be a []T slice, where T is the Go type
+// of arrayType.DType, with length equal to arrayType.Size(). It is used without copying.
+func FromFlatAny(arrayType atype.ArrayType, flat any) (*Tensor, error) {
+	if err := checkArrayType(arrayType); err != nil {
+		return nil, err
+	}
+	wantType := reflect.SliceOf(arrayType.DType.GoType())
+	flatValue := reflect.ValueOf(flat)
+	if !flatValue.IsValid() || flatValue.Type() != wantType {
+		return nil, errors.Errorf("tensor.FromFlatAny(%s): flat must be of type %s, got %T", arrayType, wantType, flat)
+	}
+	if flatValue.Len() != arrayType.Size() {
+		return nil, errors.Errorf("tensor.FromFlatAny(%s): flat has %d elements, wanted %d", arrayType, flatValue.Len(), arrayType.Size())
+	}
+	return newContiguous(arrayType, flat), nil
+}
+
+// ArrayType returns the array type of the Tensor. It implements atype.HasArrayType.
+func (t *Tensor) ArrayType() atype.ArrayType { return t.arrayType }
+
+// DType returns the dtype of the Tensor elements.
+func (t *Tensor) DType() dtype.DType { return t.arrayType.DType }
+
+// NumAxes returns the number of axes of the Tensor.
+func (t *Tensor) NumAxes() int { return t.arrayType.NumAxes() }
+
+// Size returns the number of elements of the Tensor.
+func (t *Tensor) Size() int { return t.arrayType.Size() }
+
+// Flat returns a flat slice `[]T` (T is the Go type of the dtype) holding the data in row-major order.
+//
+// If the Tensor is contiguous (see IsContiguous) it is not a copy: changes to it are reflected in the Tensor.
+// Otherwise, it returns a copy of the elements.
+func (t *Tensor) Flat() any { return t.contiguousFlat().Interface() }
+
+// FlatOf returns the flat slice holding the data of t in row-major order. See Tensor.Flat.
+//
+// It returns an error if T is not the Go type of t's dtype.
+func FlatOf[T dtype.Supported](t *Tensor) ([]T, error) {
+	flat, ok := t.Flat().([]T)
+	if !ok {
+		var zero T
+		return nil, errors.Errorf("tensor.FlatOf[%T]: tensor has dtype %s", zero, t.DType())
+	}
+	return flat, nil
+}
+
+// contiguousFlat returns the elements of t in row-major order: a sub-slice of the storage if t is contiguous,
+// or a copy otherwise.
+func (t *Tensor) contiguousFlat() reflect.Value {
+	flat := reflect.ValueOf(t.flat)
+	size := t.Size()
+	if size == 0 {
+		// The offset of empty views may be past the end of the storage.
+		return reflect.MakeSlice(flat.Type(), 0, 0)
+	}
+	if t.IsContiguous() {
+		return flat.Slice(t.layout.Offset, t.layout.Offset+size)
+	}
+	contiguous := reflect.MakeSlice(flat.Type(), size, size)
+	for flatIdx, indices := range t.arrayType.Iter() {
+		contiguous.Index(flatIdx).Set(flat.Index(t.layout.FlatIndex(indices)))
+	}
+	return contiguous
+}
+
+// flatIndex converts the given indices to the position in the flat slice.
+// It panics if the indices are out-of-bounds, like slice indexing.
+func (t *Tensor) flatIndex(indices []int) int {
+	if len(indices) != t.NumAxes() {
+		panic(errors.Errorf("tensor: got %d indices for tensor with array type %s", len(indices), t.arrayType))
+	}
+	for axis, idx := range indices {
+		length := t.arrayType.AxisLengths[axis]
+		if idx < 0 || idx >= length {
+			panic(errors.Errorf("tensor: index %d out-of-bounds for axis %d with length %d (indices=%v)", idx, axis, length, indices))
+		}
+	}
+	return t.layout.FlatIndex(indices)
+}
+
+// At returns the element at the given indices, one per axis. For a scalar, no indices are given.
+//
+// Like with slice indexing, it panics if the number of indices is wrong or if they are out-of-bounds.
+func (t *Tensor) At(indices ...int) any {
+	return reflect.ValueOf(t.flat).Index(t.flatIndex(indices)).Interface()
+}
+
+// Set sets the element at the given indices to value, converting it to the dtype of the Tensor
+// if needed (see atype.CastAsDType).
+//
+// Like with slice indexing, it panics if the number of indices is wrong or if they are out-of-bounds.
+func (t *Tensor) Set(value any, indices ...int) {
+	if reflect.TypeOf(value) != t.DType().GoType() {
+		value = atype.CastAsDType(value, t.DType())
+	}
+	reflect.ValueOf(t.flat).Index(t.flatIndex(indices)).Set(reflect.ValueOf(value))
+}
+
+// Value returns a copy of the Tensor data as a Go value: a scalar for a Tensor with no axes, or
+// a multi-level slice (e.g. [][]float32 for a 2-axes float32 Tensor) otherwise.
+func (t *Tensor) Value() any {
+	flat := t.contiguousFlat()
+	if t.arrayType.IsScalar() {
+		return flat.Index(0).Interface()
+	}
+	var pos int
+	return valueFromFlat(flat, t.arrayType.AxisLengths, &pos).Interface()
+}
+
+// valueFromFlat recursively builds the multi-level slice for the given axis lengths, consuming flat from *pos.
+func valueFromFlat(flat reflect.Value, axisLengths []int, pos *int) reflect.Value {
+	length := axisLengths[0]
+	if len(axisLengths) == 1 {
+		v := reflect.MakeSlice(flat.Type(), length, length)
+		*pos += reflect.Copy(v, flat.Slice(*pos, *pos+length))
+		return v
+	}
+	sliceType := flat.Type()
+	for range axisLengths {
+		sliceType = reflect.SliceOf(sliceType)
+	}
+	sliceType = sliceType.Elem()
+	v := reflect.MakeSlice(sliceType, length, length)
+	for ii := 0; ii < length; ii++ {
+		v.Index(ii).Set(valueFromFlat(flat, axisLengths[1:], pos))
+	}
+	return v
+}
+
+// Clone returns a deep copy of the Tensor. The copy is always contiguous, even if t is a view.
+func (t *Tensor) Clone() *Tensor {
+	flat := t.contiguousFlat()
+	cloned := reflect.MakeSlice(flat.Type(), flat.Len(), flat.Len())
+	reflect.Copy(cloned, flat)
+	return newContiguous(t.arrayType, cloned.Interface())
+}
@@ -0,0 +1,102 @@
+package tensor
+
+import (
+	"testing"
+
+	"github.com/sebffischer/backend/backend/atype"
+	"github.com/sebffischer/backend/backend/dtype"
+	"github.com/sebffischer/backend/backend/dtype/bfloat16"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromValue(t *testing.T) {
+	value := [][]float32{{1, 2, 3}, {4, 5, 6}}
+	tensor, err := FromValue(value)
+	require.NoError(t, err)
+	require.True(t, tensor.ArrayType().Equal(atype.Make(dtype.Float32, 2, 3)))
+	require.Equal(t, []float32{1, 2, 3, 4, 5, 6}, tensor.Flat())
+	require.Equal(t, float32(6), tensor.At(1, 2))
+	require.Equal(t, value, tensor.Value())
+
+	// Value is a copy.
+	got := tensor.Value().([][]float32)
+	got[0][0] = 100
+	require.Equal(t, float32(1), tensor.At(0, 0))
+
+	// Scalar.
+	tensor, err = FromValue(int64(7))
+	require.NoError(t, err)
+	require.True(t, tensor.ArrayType().IsScalar())
+	require.Equal(t, int64(7), tensor.At())
+	require.Equal(t, int64(7), tensor.Value())
+
+	// Go int is stored as Int64.
+	tensor, err = FromValue([]int{1, 2, 3})
+	require.NoError(t, err)
+	require.True(t, tensor.ArrayType().Equal(atype.Make(dtype.Int64, 3)))
+	require.Equal(t, []int64{1, 2, 3}, tensor.Flat())
+	tensor.Set(5, 0)
+	require.Equal(t, int64(5), tensor.At(0))
+	tensor, err = FromValue(7)
+	require.NoError(t, err)
+	require.Equal(t, int64(7), tensor.At())
+
+	// Non-native dtypes.
+	tensor, err = FromValue([][][]bfloat16.BFloat16{{{bfloat16.FromFloat32(1)}, {bfloat16.FromFloat32(2)}}})
+	require.NoError(t, err)
+	require.True(t, tensor.ArrayType().Equal(atype.Make(dtype.BFloat16, 1, 2, 1)))
+	require.Equal(t, bfloat16.FromFloat32(2), tensor.At(0, 1, 0))
+
+	// Irregular slices.
+	_, err = FromValue([][]int8{{1}, {2, 3}})
+	require.Error(t, err)
+}
+
+func TestFromFlat(t *testing.T) {
+	flat := []int32{1, 2, 3, 4, 5, 6}
+	tensor, err := FromFlat(flat, 3, 2)
+	require.NoError(t, err)
+	require.Equal(t, [][]int32{{1, 2}, {3, 4}, {5, 6}}, tensor.Value())
+
+	// No copy: changes to flat are visible in the tensor and vice-versa.
+	flat[5] = 60
+	require.Equal(t, int32(60), tensor.At(2, 1))
+	tensor.Set(10, 0, 0)
+	require.Equal(t, int32(10), flat[0])
+
+	got, err := FlatOf[int32](tensor)
+	require.NoError(t, err)
+	require.Equal(t, flat, got)
+	_, err = FlatOf[float32](tensor)
+	require.Error(t, err)
+
+	// Wrong number of elements or type.
+	_, err = FromFlat(flat, 4, 2)
+	require.Error(t, err)
+	_, err = FromFlatAny(atype.Make(dtype.Float32, 6), flat)
+	require.Error(t, err)
+
+	// Out-of-bounds indices.
+	require.Panics(t, func() { _ = tensor.At(3, 0) })
+	require.Panics(t, func() { _ = tensor.At(0) })
+}
+
+func TestNew(t *testing.T) {
+	tensor, err := New(atype.Make(dtype.Float64, 2, 0, 3))
+	require.NoError(t, err)
+	require.Equal(t, 0, tensor.Size())
+	require.Equal(t, [][][]float64{{}, {}}, tensor.Value())
+
+	tensor, err = New(atype.Make(dtype.Uint8, 2))
+	require.NoError(t, err)
+	require.Equal(t, []uint8{0, 0}, tensor.Value())
+	cloned := tensor.Clone()
+	cloned.Set(uint8(3), 1)
+	require.Equal(t, uint8(0), tensor.At(1))
+	require.Equal(t, uint8(3), cloned.At(1))
+
+	_, err = New(atype.Invalid())
+	require.Error(t, err)
+	_, err = New(atype.Make(dtype.S4, 2))
+	require.Error(t, err)
+}
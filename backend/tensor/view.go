@@ -0,0 +1,99 @@
+package tensor
+
+import (
+	"slices"
+
+	"github.com/pkg/errors"
+	"github.com/sebffischer/backend/backend/atype"
+)
+
+// IsContiguous returns whether the elements of the Tensor are stored contiguously in row-major order.
+// Tensors created by New, FromValue or FromFlat are contiguous, views created by Slice or T may not be.
+func (t *Tensor) IsContiguous() bool {
+	return t.layout.IsRowMajor(t.arrayType)
+}
+
+// Contiguous returns t if it is contiguous, or a contiguous copy of it otherwise.
+func (t *Tensor) Contiguous() *Tensor {
+	if t.IsContiguous() {
+		return t
+	}
+	return t.Clone()
+}
+
+// Slice returns a view of t restricted to the indices [start, end) of the given axis, without copying.
+// Negative axis values count from the end, so -1 is the last axis.
+//
+// The view shares the storage with t: changes to one are reflected in the other.
+func (t *Tensor) Slice(axis, start, end int) (*Tensor, error) {
+	length, err := t.arrayType.AxisLengthE(axis)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Tensor.Slice")
+	}
+	if axis < 0 {
+		axis += t.NumAxes()
+	}
+	if start < 0 || start > end || end > length {
+		return nil, errors.Errorf("Tensor.Slice(axis=%d, start=%d, end=%d): invalid range for axis with length %d", axis, start, end, length)
+	}
+	view := t.view()
+	view.arrayType.AxisLengths[axis] = end - start
+	if end > start {
+		// Empty views keep the offset, so it stays within the storage.
+		view.layout.Offset += start * t.layout.Strides[axis]
+	}
+	return view, nil
+}
+
+// T returns a view of t with its axes permuted, without copying: axis i of the view is axis permutation[i] of t.
+// If no permutation is given, the order of the axes is reversed, so for matrices it's the transpose.
+//
+// The view shares the storage with t: changes to one are reflected in the other.
+func (t *Tensor) T(permutation ...int) (*Tensor, error) {
+	numAxes := t.NumAxes()
+	if len(permutation) == 0 {
+		permutation = make([]int, numAxes)
+		for ii := range permutation {
+			permutation[ii] = numAxes - 1 - ii
+		}
+	}
+	if len(permutation) != numAxes {
+		return nil, errors.Errorf("Tensor.T(%v): permutation must have one entry per axis (%d)", permutation, numAxes)
+	}
+	seen := make([]bool, numAxes)
+	view := t.view()
+	for ii, axis := range permutation {
+		if axis < 0 || axis >= numAxes || seen[axis] {
+			return nil, errors.Errorf("Tensor.T(%v): invalid permutation for %d axes", permutation, numAxes)
+		}
+		seen[axis] = true
+		view.arrayType.AxisLengths[ii] = t.arrayType.AxisLengths[axis]
+		view.layout.Strides[ii] = t.layout.Strides[axis]
+	}
+	return view, nil
+}
+
+// Reshape returns a view of t with the new axis lengths, without copying. The number of elements must be the same.
+//
+// It returns an error if t is not contiguous: use Contiguous().Reshape(...) in that case, which will copy the data.
+func (t *Tensor) Reshape(axisLengths ...int) (*Tensor, error) {
+	if !t.IsContiguous() {
+		return nil, errors.Errorf("Tensor.Reshape(%v): tensor with array type %s is not contiguous, use Contiguous() first", axisLengths, t.arrayType)
+	}
+	arrayType, err := atype.Reshape(t.arrayType, axisLengths...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "Tensor.Reshape")
+	}
+	layout := atype.RowMajorLayout(arrayType)
+	layout.Offset = t.layout.Offset
+	return &Tensor{arrayType: arrayType, flat: t.flat, layout: layout}, nil
+}
+
+// view returns a shallow copy of t, sharing the storage, with its own copies of the array type and layout.
+func (t *Tensor) view() *Tensor {
+	return &Tensor{
+		arrayType: t.arrayType.Clone(),
+		flat:      t.flat,
+		layout:    atype.Layout{Offset: t.layout.Offset, Strides: slices.Clone(t.layout.Strides)},
+	}
+}
@@ -0,0 +1,88 @@
+package tensor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestViews(t *testing.T) {
+	flat := []int32{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}
+	tensor, err := FromFlat(flat, 3, 4)
+	require.NoError(t, err)
+	require.True(t, tensor.IsContiguous())
+
+	// Slice rows: still contiguous, shares storage.
+	rows, err := tensor.Slice(0, 1, 3)
+	require.NoError(t, err)
+	require.True(t, rows.IsContiguous())
+	require.Equal(t, [][]int32{{4, 5, 6, 7}, {8, 9, 10, 11}}, rows.Value())
+	require.Equal(t, []int32{4, 5, 6, 7, 8, 9, 10, 11}, rows.Flat())
+	rows.Set(int32(-4), 0, 0)
+	require.Equal(t, int32(-4), flat[4])
+
+	// Slice columns: not contiguous.
+	cols, err := tensor.Slice(-1, 1, 3)
+	require.NoError(t, err)
+	require.False(t, cols.IsContiguous())
+	require.Equal(t, [][]int32{{1, 2}, {5, 6}, {9, 10}}, cols.Value())
+	require.Equal(t, []int32{1, 2, 5, 6, 9, 10}, cols.Flat())
+	require.Equal(t, int32(10), cols.At(2, 1))
+
+	// Transpose.
+	transposed, err := tensor.T()
+	require.NoError(t, err)
+	require.False(t, transposed.IsContiguous())
+	require.Equal(t, [][]int32{{0, -4, 8}, {1, 5, 9}, {2, 6, 10}, {3, 7, 11}}, transposed.Value())
+	transposed.Set(int32(100), 3, 2)
+	require.Equal(t, int32(100), flat[11])
+
+	// Reshape only works on contiguous tensors.
+	_, err = transposed.Reshape(12)
+	require.Error(t, err)
+	reshaped, err := transposed.Contiguous().Reshape(2, 6)
+	require.NoError(t, err)
+	require.Equal(t, [][]int32{{0, -4, 8, 1, 5, 9}, {2, 6, 10, 3, 7, 100}}, reshaped.Value())
+	reshaped, err = rows.Reshape(8)
+	require.NoError(t, err)
+	require.Equal(t, []int32{-4, 5, 6, 7, 8, 9, 10, 100}, reshaped.Value())
+
+	// Clone of a view is contiguous.
+	cloned := cols.Clone()
+	require.True(t, cloned.IsContiguous())
+	require.Equal(t, cols.Value(), cloned.Value())
+
+	// Empty views, including empty views of empty views.
+	empty, err := tensor.Slice(0, 3, 3)
+	require.NoError(t, err)
+	empty, err = empty.Slice(1, 4, 4)
+	require.NoError(t, err)
+	require.Equal(t, []int32{}, empty.Flat())
+	require.Equal(t, 0, empty.Clone().Size())
+	small, err := FromFlat([]int32{0, 1, 2, 3, 4, 5}, 2, 3)
+	require.NoError(t, err)
+	empty, err = small.Slice(0, 2, 2)
+	require.NoError(t, err)
+	empty, err = empty.Slice(1, 3, 3)
+	require.NoError(t, err)
+	require.Equal(t, [][]int32{}, empty.Value())
+	require.Equal(t, []int32{}, empty.Flat())
+
+	// Invalid arguments.
+	_, err = tensor.Slice(2, 0, 1)
+	require.Error(t, err)
+	_, err = tensor.Slice(0, 2, 4)
+	require.Error(t, err)
+	_, err = tensor.T(0, 0)
+	require.Error(t, err)
+	_, err = tensor.Reshape(5)
+	require.Error(t, err)
+}
+
+func TestViewString(t *testing.T) {
+	tensor, err := FromValue([][]int8{{1, 2}, {3, 4}})
+	require.NoError(t, err)
+	transposed, err := tensor.T()
+	require.NoError(t, err)
+	require.Equal(t, "(Int8)[2 2]\n[[1 3]\n [2 4]]", transposed.String())
+}